@@ -7,4 +7,5 @@ const (
 	Para         = "??"
 	SchPG Schema = iota
 	SchMYSQL
+	SchSQLite
 )
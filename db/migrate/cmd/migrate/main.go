@@ -0,0 +1,120 @@
+// Command migrate applies the versioned schema migrations to Postgres.
+//
+//	migrate [flags] up [n]    apply n or all pending migrations
+//	migrate [flags] down [n]  roll back n or the latest applied migration
+//	migrate [flags] redo      roll back and apply the latest migration again
+//	migrate [flags] status    list the migrations and their states
+//
+// The password is read from the environment variable PGPASSWORD if the flag
+// -password is not given.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"text/tabwriter"
+
+	"github.com/secure-for-ai/secureai-microsvs/db/migrate"
+	"github.com/secure-for-ai/secureai-microsvs/db/pgdb"
+	"github.com/secure-for-ai/secureai-microsvs/log"
+)
+
+func main() {
+	conf := pgdb.PGPoolConf{}
+	conf.RegisterFlags(flag.CommandLine)
+	dir := flag.String("dir", "migrations", "directory of the migration files")
+	table := flag.String("table", migrate.DefaultTable, "table tracking the applied migrations")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s [flags] up [n] | down [n] | redo | status\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	conf.ReadEnv()
+
+	if flag.NArg() < 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	n := 0
+	if flag.NArg() > 1 {
+		var err error
+		if n, err = strconv.Atoi(flag.Arg(1)); err != nil {
+			log.Fatalf("invalid number of migrations: %s\n", flag.Arg(1))
+		}
+	}
+
+	client, err := pgdb.NewPGClient(conf)
+	if err != nil {
+		log.Fatalf("cannot connect to postgres: %v\n", err)
+	}
+	defer client.Close()
+
+	m, err := migrate.NewFromDir(client, *dir)
+	if err != nil {
+		log.Fatalf("cannot load migrations: %v\n", err)
+	}
+	m.SetTable(*table)
+
+	if err = run(context.Background(), m, flag.Arg(0), n); err != nil {
+		client.Close()
+		log.Fatalf("%v\n", err)
+	}
+}
+
+func run(ctx context.Context, m *migrate.Migrator, cmd string, n int) error {
+	switch cmd {
+	case "up":
+		migrated, err := m.Up(ctx, n)
+		for _, mig := range migrated {
+			fmt.Println("applied", mig)
+		}
+		if err == nil && len(migrated) == 0 {
+			fmt.Println("no pending migration")
+		}
+		return err
+	case "down":
+		migrated, err := m.Down(ctx, n)
+		for _, mig := range migrated {
+			fmt.Println("rolled back", mig)
+		}
+		if err == nil && len(migrated) == 0 {
+			fmt.Println("no applied migration")
+		}
+		return err
+	case "redo":
+		mig, err := m.Redo(ctx)
+		if mig != nil {
+			fmt.Println("redone", mig)
+		} else if err == nil {
+			fmt.Println("no applied migration")
+		}
+		return err
+	case "status":
+		status, err := m.Status(ctx)
+		if err != nil {
+			return err
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(w, "VERSION\tNAME\tSTATE\tAPPLIED AT")
+		for _, st := range status {
+			state, appliedAt := "pending", ""
+			if st.Applied {
+				state = "applied"
+				appliedAt = st.AppliedAt.Format("2006-01-02 15:04:05 MST")
+			}
+			if st.Modified {
+				state = "modified"
+			} else if st.Missing {
+				state = "missing"
+			}
+			fmt.Fprintf(w, "%d\t%s\t%s\t%s\n", st.Version, st.Name, state, appliedAt)
+		}
+		return w.Flush()
+	}
+	return fmt.Errorf("unknown command: %s", cmd)
+}
@@ -0,0 +1,359 @@
+package migrate
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"io/fs"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/secure-for-ai/secureai-microsvs/db/pgdb"
+)
+
+// DefaultTable is the default table tracking the applied versions
+const DefaultTable = "schema_migrations"
+
+// Migrator applies and rolls back the migrations. Every migration runs in its
+// own transaction together with the update of the tracking table, and the
+// concurrent runners are serialized by a Postgres advisory lock.
+type Migrator struct {
+	Client     *pgdb.PGClient
+	Migrations []*Migration
+	// Table is the tracking table, which can be qualified by the schema,
+	// e.g., "test.schema_migrations".
+	Table string
+	// LockKey is the key of the advisory lock, which is derived from Table
+	// by default.
+	LockKey int64
+}
+
+// New loads the migrations in dir of fsys, e.g., an embed.FS.
+func New(client *pgdb.PGClient, fsys fs.FS, dir string) (*Migrator, error) {
+	migrations, err := Load(fsys, dir)
+	if err != nil {
+		return nil, err
+	}
+
+	m := &Migrator{
+		Client:     client,
+		Migrations: migrations,
+	}
+	m.SetTable(DefaultTable)
+	return m, nil
+}
+
+// NewFromDir loads the migrations in the directory
+func NewFromDir(client *pgdb.PGClient, dir string) (*Migrator, error) {
+	return New(client, os.DirFS(dir), ".")
+}
+
+// SetTable sets the tracking table and derives the key of the advisory lock
+// from it, so that the runners of different tables do not block each other.
+func (m *Migrator) SetTable(table string) {
+	h := fnv.New64a()
+	h.Write([]byte("migrate:" + table))
+	m.Table = table
+	m.LockKey = int64(h.Sum64())
+}
+
+// Status is the state of a migration
+type Status struct {
+	Version int64
+	Name    string
+	Applied bool
+	// AppliedAt is zero if the migration is not applied
+	AppliedAt time.Time
+	// Modified reports that the applied migration has been changed since
+	// it was applied.
+	Modified bool
+	// Missing reports that the applied migration is not found in the source
+	Missing bool
+}
+
+// appliedVersion is a row of the tracking table
+type appliedVersion struct {
+	Version   int64     `db:"version"`
+	Name      string    `db:"name"`
+	Checksum  string    `db:"checksum"`
+	AppliedAt time.Time `db:"applied_at"`
+}
+
+// session is the connection holding the advisory lock
+type session struct {
+	m     *Migrator
+	conn  *pgdb.PGConn
+	table string
+}
+
+// connect acquires a connection without the advisory lock
+func (m *Migrator) connect(ctx context.Context) (*session, error) {
+	conn, err := m.Client.GetConn(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return &session{
+		m:     m,
+		conn:  conn,
+		table: pgx.Identifier(strings.Split(m.Table, ".")).Sanitize(),
+	}, nil
+}
+
+// lock acquires a connection and the advisory lock, and creates the tracking
+// table if it does not exist.
+func (m *Migrator) lock(ctx context.Context) (*session, error) {
+	s, err := m.connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	conn := s.conn
+
+	// session level lock, which is released when the connection is closed
+	// even if the unlock fails.
+	if _, err = conn.Exec(ctx, "SELECT pg_advisory_lock($1)", m.LockKey); err != nil {
+		conn.Release()
+		return nil, err
+	}
+
+	_, err = conn.Exec(ctx, "CREATE TABLE IF NOT EXISTS "+s.table+` (
+	version bigint NOT NULL PRIMARY KEY,
+	name text NOT NULL,
+	checksum text NOT NULL,
+	applied_at timestamptz NOT NULL DEFAULT now()
+)`)
+	if err != nil {
+		s.unlock()
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *session) unlock() {
+	_, err := s.conn.Exec(context.Background(), "SELECT pg_advisory_unlock($1)", s.m.LockKey)
+	if err != nil {
+		// the lock cannot be released, close the connection instead
+		_ = s.conn.Conn.Conn().Close(context.Background())
+	}
+	s.conn.Release()
+}
+
+// tableExists checks whether the tracking table has been created
+func (s *session) tableExists(ctx context.Context) (bool, error) {
+	var exists bool
+	err := s.conn.QueryRow(ctx, "SELECT to_regclass($1) IS NOT NULL", s.table).Scan(&exists)
+	return exists, err
+}
+
+// applied returns the applied versions in ascending order
+func (s *session) applied(ctx context.Context) ([]appliedVersion, error) {
+	var versions []appliedVersion
+	_, err := s.conn.FindAll(ctx, "SELECT version, name, checksum, applied_at FROM "+s.table+
+		" ORDER BY version", &versions)
+	return versions, err
+}
+
+// verify checks the applied migrations are neither modified nor missing
+func (s *session) verify(applied []appliedVersion) error {
+	for _, v := range applied {
+		mig := s.m.find(v.Version)
+		if mig == nil {
+			return fmt.Errorf("%w: %d_%s", ErrMissingMigration, v.Version, v.Name)
+		}
+		if mig.Checksum != v.Checksum {
+			return fmt.Errorf("%w: %s", ErrChecksumMismatch, mig)
+		}
+	}
+	return nil
+}
+
+func (m *Migrator) find(version int64) *Migration {
+	for _, mig := range m.Migrations {
+		if mig.Version == version {
+			return mig
+		}
+	}
+	return nil
+}
+
+// run executes the sql and updates the tracking table in a transaction
+func (s *session) run(ctx context.Context, mig *Migration, up bool) error {
+	tx, err := s.conn.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	sql := mig.Down
+	if up {
+		sql = mig.Up
+	}
+	// no argument is given, so the sql of several statements is run by
+	// the simple protocol.
+	if _, err = tx.Exec(ctx, sql); err != nil {
+		return fmt.Errorf("migrate: %s: %w", mig, err)
+	}
+
+	if up {
+		_, err = tx.Exec(ctx, "INSERT INTO "+s.table+" (version, name, checksum) VALUES ($1, $2, $3)",
+			mig.Version, mig.Name, mig.Checksum)
+	} else {
+		_, err = tx.Exec(ctx, "DELETE FROM "+s.table+" WHERE version = $1", mig.Version)
+	}
+	if err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+func (s *session) up(ctx context.Context, n int) ([]*Migration, error) {
+	applied, err := s.applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err = s.verify(applied); err != nil {
+		return nil, err
+	}
+
+	done := make(map[int64]bool, len(applied))
+	for _, v := range applied {
+		done[v.Version] = true
+	}
+
+	var migrated []*Migration
+	for _, mig := range s.m.Migrations {
+		if n > 0 && len(migrated) == n {
+			break
+		}
+		if done[mig.Version] {
+			continue
+		}
+		if err = s.run(ctx, mig, true); err != nil {
+			return migrated, err
+		}
+		migrated = append(migrated, mig)
+	}
+	return migrated, nil
+}
+
+func (s *session) down(ctx context.Context, n int) ([]*Migration, error) {
+	applied, err := s.applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err = s.verify(applied); err != nil {
+		return nil, err
+	}
+
+	var migrated []*Migration
+	for i := len(applied) - 1; i >= 0 && len(migrated) < n; i-- {
+		mig := s.m.find(applied[i].Version)
+		if strings.TrimSpace(mig.Down) == "" {
+			return migrated, fmt.Errorf("%w: %s", ErrNoDownMigration, mig)
+		}
+		if err = s.run(ctx, mig, false); err != nil {
+			return migrated, err
+		}
+		migrated = append(migrated, mig)
+	}
+	return migrated, nil
+}
+
+// Up applies n pending migrations in the order of versions, or all of them
+// if n is not positive. The pending migration older than the applied ones is
+// applied as well. It returns the applied migrations.
+func (m *Migrator) Up(ctx context.Context, n int) ([]*Migration, error) {
+	s, err := m.lock(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer s.unlock()
+
+	return s.up(ctx, n)
+}
+
+// Down rolls back the n latest applied migrations, or the latest one if n is
+// not positive. It returns the rolled back migrations.
+func (m *Migrator) Down(ctx context.Context, n int) ([]*Migration, error) {
+	s, err := m.lock(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer s.unlock()
+
+	return s.down(ctx, max(n, 1))
+}
+
+// Redo rolls back and applies the latest applied migration again under the
+// same lock. It returns the migration redone.
+func (m *Migrator) Redo(ctx context.Context) (*Migration, error) {
+	s, err := m.lock(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer s.unlock()
+
+	migrated, err := s.down(ctx, 1)
+	if err != nil || len(migrated) == 0 {
+		return nil, err
+	}
+
+	mig := migrated[0]
+	if err = s.run(ctx, mig, true); err != nil {
+		return nil, err
+	}
+	return mig, nil
+}
+
+// Status reports the state of the migrations in the source, followed by the
+// applied ones missing in the source. It is read-only, so it neither takes the
+// advisory lock nor creates the tracking table, and all the migrations are
+// pending if the table does not exist.
+func (m *Migrator) Status(ctx context.Context) ([]Status, error) {
+	s, err := m.connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer s.conn.Release()
+
+	var applied []appliedVersion
+	exists, err := s.tableExists(ctx)
+	if err == nil && exists {
+		applied, err = s.applied(ctx)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	versions := make(map[int64]appliedVersion, len(applied))
+	for _, v := range applied {
+		versions[v.Version] = v
+	}
+
+	status := make([]Status, 0, len(m.Migrations))
+	for _, mig := range m.Migrations {
+		st := Status{Version: mig.Version, Name: mig.Name}
+		if v, ok := versions[mig.Version]; ok {
+			st.Applied = true
+			st.AppliedAt = v.AppliedAt
+			st.Modified = v.Checksum != mig.Checksum
+		}
+		status = append(status, st)
+	}
+	for _, v := range applied {
+		if m.find(v.Version) == nil {
+			status = append(status, Status{
+				Version:   v.Version,
+				Name:      v.Name,
+				Applied:   true,
+				AppliedAt: v.AppliedAt,
+				Missing:   true,
+			})
+		}
+	}
+	return status, nil
+}
@@ -0,0 +1,128 @@
+package migrate
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+var (
+	// ErrNoUpMigration the up file of a version is missing
+	ErrNoUpMigration = errors.New("migrate: up migration not found")
+	// ErrNoDownMigration the down file of a version is missing
+	ErrNoDownMigration = errors.New("migrate: down migration not found")
+	// ErrDuplicateVersion two files have the same version and direction
+	ErrDuplicateVersion = errors.New("migrate: duplicate migration version")
+	// ErrMissingMigration an applied version is not found in the source
+	ErrMissingMigration = errors.New("migrate: applied migration not found in source")
+	// ErrChecksumMismatch the applied migration has been modified
+	ErrChecksumMismatch = errors.New("migrate: checksum mismatch")
+)
+
+// Migration is a version of the schema, which is loaded from the files
+// "<version>_<name>.up.sql" and "<version>_<name>.down.sql", e.g.,
+// "0001_create_user.up.sql".
+type Migration struct {
+	Version int64
+	Name    string
+	Up      string
+	Down    string
+	// Checksum is the SHA-256 of Up in hex, which detects the modification
+	// of the applied migration.
+	Checksum string
+}
+
+func (m *Migration) String() string {
+	return fmt.Sprintf("%d_%s", m.Version, m.Name)
+}
+
+// parseFileName parses "<version>_<name>.<up|down>.sql"
+func parseFileName(fileName string) (version int64, name string, up bool, ok bool) {
+	base, found := strings.CutSuffix(fileName, ".sql")
+	if !found {
+		return 0, "", false, false
+	}
+
+	switch {
+	case strings.HasSuffix(base, ".up"):
+		base, up = strings.TrimSuffix(base, ".up"), true
+	case strings.HasSuffix(base, ".down"):
+		base = strings.TrimSuffix(base, ".down")
+	default:
+		return 0, "", false, false
+	}
+
+	v, name, _ := strings.Cut(base, "_")
+	version, err := strconv.ParseInt(v, 10, 64)
+	if err != nil || version <= 0 {
+		return 0, "", false, false
+	}
+	return version, name, up, true
+}
+
+// Load reads the migrations in dir of fsys, which is either an embed.FS or
+// os.DirFS. The files not named as migrations are ignored. The migrations are
+// sorted by version.
+func Load(fsys fs.FS, dir string) ([]*Migration, error) {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return nil, err
+	}
+
+	versions := make(map[int64]*Migration)
+	downs := make(map[int64]bool)
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		version, name, up, ok := parseFileName(entry.Name())
+		if !ok {
+			continue
+		}
+
+		b, err := fs.ReadFile(fsys, path.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+
+		m, ok := versions[version]
+		if !ok {
+			m = &Migration{Version: version, Name: name}
+			versions[version] = m
+		}
+
+		if up {
+			if m.Checksum != "" {
+				return nil, fmt.Errorf("%w: %d", ErrDuplicateVersion, version)
+			}
+			m.Name = name
+			m.Up = string(b)
+			sum := sha256.Sum256(b)
+			m.Checksum = hex.EncodeToString(sum[:])
+		} else {
+			if downs[version] {
+				return nil, fmt.Errorf("%w: %d", ErrDuplicateVersion, version)
+			}
+			downs[version] = true
+			m.Down = string(b)
+		}
+	}
+
+	migrations := make([]*Migration, 0, len(versions))
+	for _, m := range versions {
+		if m.Checksum == "" {
+			return nil, fmt.Errorf("%w: %d", ErrNoUpMigration, m.Version)
+		}
+		migrations = append(migrations, m)
+	}
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+	return migrations, nil
+}
@@ -0,0 +1,46 @@
+package migrate
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoad(t *testing.T) {
+	fsys := fstest.MapFS{
+		"migrations/0002_add_email.up.sql":     {Data: []byte("ALTER TABLE users ADD email text;")},
+		"migrations/0002_add_email.down.sql":   {Data: []byte("ALTER TABLE users DROP email;")},
+		"migrations/0001_create_users.up.sql":  {Data: []byte("CREATE TABLE users (uid bigint PRIMARY KEY);")},
+		"migrations/0010_seed.up.sql":          {Data: []byte("INSERT INTO users VALUES (1);")},
+		"migrations/README.md":                 {Data: []byte("not a migration")},
+		"migrations/create_users.up.sql":       {Data: []byte("no version")},
+		"migrations/0001_create_users.down.go": {Data: []byte("not sql")},
+	}
+
+	migrations, err := Load(fsys, "migrations")
+	assert.NoError(t, err)
+	assert.Len(t, migrations, 3)
+
+	assert.EqualValues(t, 1, migrations[0].Version)
+	assert.EqualValues(t, "create_users", migrations[0].Name)
+	assert.EqualValues(t, "", migrations[0].Down)
+	assert.EqualValues(t, 2, migrations[1].Version)
+	assert.EqualValues(t, "ALTER TABLE users DROP email;", migrations[1].Down)
+	assert.EqualValues(t, 10, migrations[2].Version)
+	assert.EqualValues(t, "10_seed", migrations[2].String())
+	// sha256 of the up file
+	assert.Len(t, migrations[0].Checksum, 64)
+	assert.NotEqual(t, migrations[0].Checksum, migrations[1].Checksum)
+
+	// the down file without the up file
+	fsys["migrations/0003_drop.down.sql"] = &fstest.MapFile{Data: []byte("DROP TABLE users;")}
+	_, err = Load(fsys, "migrations")
+	assert.ErrorIs(t, err, ErrNoUpMigration)
+	delete(fsys, "migrations/0003_drop.down.sql")
+
+	// the same version of different names
+	fsys["migrations/002_other.up.sql"] = &fstest.MapFile{Data: []byte("SELECT 1;")}
+	_, err = Load(fsys, "migrations")
+	assert.ErrorIs(t, err, ErrDuplicateVersion)
+}
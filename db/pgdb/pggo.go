@@ -21,6 +21,7 @@ type PGQuerier interface {
 	Prepare(ctx context.Context, name string, sql string) (sd *pgconn.StatementDescription, err error)
 	ExecRowsAffected(ctx context.Context, sql string, args ...any) (int64, error)
 	SendBatch(ctx context.Context, b *pgx.Batch) pgx.BatchResults
+	CopyFrom(ctx context.Context, tableName pgx.Identifier, columnNames []string, rowSrc pgx.CopyFromSource) (int64, error)
 	Deallocate(ctx context.Context, name string) error
 }
 
@@ -0,0 +1,24 @@
+package pgdb
+
+import (
+	"flag"
+	"os"
+)
+
+// RegisterFlags registers the connection flags -host, -port, -db, -user and
+// -password of the command line tools on fs. Call ReadEnv after parsing the flags.
+func (c *PGPoolConf) RegisterFlags(fs *flag.FlagSet) {
+	fs.StringVar(&c.Host, "host", "localhost", "Postgres host")
+	fs.StringVar(&c.Port, "port", "5432", "Postgres port")
+	fs.StringVar(&c.DBName, "db", "", "database name")
+	fs.StringVar(&c.User, "user", "", "database user")
+	fs.StringVar(&c.PW, "password", "", "database password, PGPASSWORD by default")
+}
+
+// ReadEnv reads the password from the environment variable PGPASSWORD if it is
+// not given. It is not the default of the flag, which is printed by -h.
+func (c *PGPoolConf) ReadEnv() {
+	if c.PW == "" {
+		c.PW = os.Getenv("PGPASSWORD")
+	}
+}
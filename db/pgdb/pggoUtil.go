@@ -11,61 +11,26 @@ import (
 
 var ErrFindNil = errors.New("pg: row not found")
 
+// getFieldMap maps the fields of rows to the field indexes of the struct t by
+// db.FieldMap. The field not mapped to the struct is -1.
 func getFieldMap(t reflect.Type, rowFields []pgconn.FieldDescription) []int {
-	tNumField := t.NumField()
-	// map the field name to field index for a struct
-	// Suppose we have struct
-	//
-	// type User struct {
-	// Uid         int64     `json:"uid" pg:"uid"`
-	// Username    string    `json:"username" pg:"username"`
-	// Password    string    `json:"password" pg:"password"`
-	//
-	// Then, tFieldNameIndexMap is map[string]int{"password":2, "uid":0, "username":1}
-	tFieldNameIndexMap := make(map[string]int, tNumField)
-	// Suppose the row field is
-	// [
-	//    {
-	//         "Name":"uid",
-	//         "TableOID":16385,
-	//         "TableAttributeNumber":1,
-	//         "DataTypeOID":20,
-	//         "DataTypeSize":8,
-	//         "TypeModifier":-1,
-	//         "Format":1},
-	//    {
-	//         "Name":"username",
-	//         "TableOID":16385,
-	//         "TableAttributeNumber":2,
-	//         "DataTypeOID":1043,
-	//         "DataTypeSize":-1,
-	//         "TypeModifier":104,
-	//         "Format":0},
-	//    {
-	//         "Name":"password",
-	//         "TableOID":16385,
-	//         "TableAttributeNumber":3,
-	//         "DataTypeOID":1043,
-	//         "DataTypeSize":-1,
-	//         "TypeModifier":104,
-	//         "Format":0}
-	// ]
-	// Then tRowFieldMap = []int{0, 1, 2}
-	tRowFieldMap := make([]int, len(rowFields))
-	for i := 0; i < tNumField; i++ {
-		_field := t.Field(i)
-		_tag := _field.Tag.Get(db.Tag)
-		if _tag != "" {
-			tFieldNameIndexMap[_tag] = i
-		} else {
-			tFieldNameIndexMap[_field.Name] = i
-		}
+	cols := make([]string, len(rowFields))
+	for i := range rowFields {
+		cols[i] = rowFields[i].Name
 	}
+	return db.FieldMap(t, cols)
+}
 
-	for i := range rowFields {
-		tRowFieldMap[i] = tFieldNameIndexMap[string(rowFields[i].Name)]
+// fieldArgs points args to the fields of v by the field map. The field of rows not
+// mapped to the struct is scanned into a placeholder and dropped.
+func fieldArgs(v reflect.Value, fieldMap []int, args []any) {
+	for i, index := range fieldMap {
+		if index < 0 {
+			args[i] = new(any)
+		} else {
+			args[i] = v.Field(index).Addr().Interface()
+		}
 	}
-	return tRowFieldMap
 }
 
 func StructScanOne(rows pgx.Rows, dest any) error {
@@ -98,11 +63,7 @@ func StructScanOne(rows pgx.Rows, dest any) error {
 
 	if rows.Next() {
 		args := make([]any, fieldsLen)
-
-		for i := range fields {
-			//args[i] = v.Field(baseFieldMap[i]).Addr().Interface()
-			args[i] = baseValue.Field(baseFieldMap[i]).Addr().Interface()
-		}
+		fieldArgs(baseValue, baseFieldMap, args)
 		err := rows.Scan(args...)
 		if err != nil {
 			return err
@@ -116,6 +77,28 @@ func StructScanOne(rows pgx.Rows, dest any) error {
 	return nil
 }
 
+// StructScanArgs returns the pointers to the fields of dest, a pointer to a struct,
+// in the order of the fields of rows. The struct is reused by scanning rows one by
+// one with rows.Scan(args...).
+func StructScanArgs(rows pgx.Rows, dest any) ([]any, error) {
+	value := reflect.ValueOf(dest)
+
+	if value.Kind() != reflect.Ptr || value.IsNil() {
+		return nil, errors.New("must pass a non-nil pointer to StructScanArgs destination")
+	}
+
+	baseValue := reflect.Indirect(value)
+	if baseValue.Kind() != reflect.Struct {
+		return nil, errors.New("must pass a pointer to a struct to StructScanArgs destination")
+	}
+
+	fields := rows.FieldDescriptions()
+	baseFieldMap := getFieldMap(baseValue.Type(), fields)
+	args := make([]any, len(fields))
+	fieldArgs(baseValue, baseFieldMap, args)
+	return args, nil
+}
+
 // It is better to pre-allocate the memory for dest, which is a slice, if
 // you know the maximum number of return records,
 // so that it won't reallocate the memory of the slice.
@@ -154,10 +137,7 @@ func StructScanSlice(rows pgx.Rows, dest any) error {
 	vp = reflect.New(base)
 	v = reflect.Indirect(vp)
 	args := make([]any, fieldsLen)
-
-	for i := range fields {
-		args[i] = v.Field(baseFieldMap[i]).Addr().Interface()
-	}
+	fieldArgs(v, baseFieldMap, args)
 	for rows.Next() {
 
 		err := rows.Scan(args...)
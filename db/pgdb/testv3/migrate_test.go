@@ -0,0 +1,159 @@
+package pgdb_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"testing/fstest"
+
+	"github.com/secure-for-ai/secureai-microsvs/db/migrate"
+	"github.com/stretchr/testify/assert"
+)
+
+const migrateTable = "test_schema_migrations"
+
+var migrateFS = fstest.MapFS{
+	"0001_create_course.up.sql":   {Data: []byte("CREATE TABLE test_course (cid bigint PRIMARY KEY, title text NOT NULL);")},
+	"0001_create_course.down.sql": {Data: []byte("DROP TABLE test_course;")},
+	"0002_seed_course.up.sql":     {Data: []byte("INSERT INTO test_course VALUES (1, 'math'), (2, 'art');")},
+	"0002_seed_course.down.sql":   {Data: []byte("DELETE FROM test_course;")},
+}
+
+// newMigrator drops the tables of the previous run and loads the migrations
+func newMigrator(t *testing.T, ctx context.Context, fsys fstest.MapFS) *migrate.Migrator {
+	_, err := client.Exec(ctx, "DROP TABLE IF EXISTS test_course, "+migrateTable)
+	assert.NoError(t, err)
+
+	m, err := migrate.New(client, fsys, ".")
+	assert.NoError(t, err)
+	m.SetTable(migrateTable)
+	return m
+}
+
+func countCourses(t *testing.T, ctx context.Context) int64 {
+	var n int64
+	err := client.QueryRow(ctx, "SELECT COUNT(*) FROM test_course").Scan(&n)
+	assert.NoError(t, err)
+	return n
+}
+
+func TestMigrate(t *testing.T) {
+	initPG()
+	defer client.Close()
+
+	ctx := context.Background()
+	m := newMigrator(t, ctx, migrateFS)
+
+	// Status is read-only, so the tracking table is not created
+	status, err := m.Status(ctx)
+	assert.NoError(t, err)
+	assert.Len(t, status, 2)
+	assert.False(t, status[0].Applied)
+	assert.False(t, status[1].Applied)
+	var exists bool
+	err = client.QueryRow(ctx, "SELECT to_regclass($1) IS NOT NULL", migrateTable).Scan(&exists)
+	assert.NoError(t, err)
+	assert.False(t, exists)
+
+	migrated, err := m.Up(ctx, 1)
+	assert.NoError(t, err)
+	assert.Len(t, migrated, 1)
+	assert.EqualValues(t, 1, migrated[0].Version)
+
+	migrated, err = m.Up(ctx, 0)
+	assert.NoError(t, err)
+	assert.Len(t, migrated, 1)
+	assert.EqualValues(t, 2, migrated[0].Version)
+	assert.EqualValues(t, 2, countCourses(t, ctx))
+
+	status, err = m.Status(ctx)
+	assert.NoError(t, err)
+	assert.True(t, status[0].Applied)
+	assert.True(t, status[1].Applied)
+	assert.False(t, status[1].AppliedAt.IsZero())
+
+	// the latest migration is rolled back and applied again
+	migrated, err = m.Down(ctx, 0)
+	assert.NoError(t, err)
+	assert.Len(t, migrated, 1)
+	assert.EqualValues(t, 2, migrated[0].Version)
+	assert.EqualValues(t, 0, countCourses(t, ctx))
+
+	migrated, err = m.Up(ctx, 0)
+	assert.NoError(t, err)
+	assert.Len(t, migrated, 1)
+
+	mig, err := m.Redo(ctx)
+	assert.NoError(t, err)
+	assert.EqualValues(t, 2, mig.Version)
+	assert.EqualValues(t, 2, countCourses(t, ctx))
+
+	migrated, err = m.Down(ctx, 2)
+	assert.NoError(t, err)
+	assert.Len(t, migrated, 2)
+	_, err = client.Exec(ctx, "SELECT 1 FROM test_course")
+	assert.Error(t, err)
+}
+
+func TestMigrateModified(t *testing.T) {
+	initPG()
+	defer client.Close()
+
+	ctx := context.Background()
+	m := newMigrator(t, ctx, migrateFS)
+	_, err := m.Up(ctx, 0)
+	assert.NoError(t, err)
+
+	// the applied migration is changed afterwards
+	fsys := fstest.MapFS{}
+	for name, file := range migrateFS {
+		fsys[name] = file
+	}
+	fsys["0002_seed_course.up.sql"] = &fstest.MapFile{Data: []byte("INSERT INTO test_course VALUES (3, 'music');")}
+	modified, err := migrate.New(client, fsys, ".")
+	assert.NoError(t, err)
+	modified.SetTable(migrateTable)
+
+	_, err = modified.Up(ctx, 0)
+	assert.ErrorIs(t, err, migrate.ErrChecksumMismatch)
+	_, err = modified.Down(ctx, 1)
+	assert.ErrorIs(t, err, migrate.ErrChecksumMismatch)
+
+	status, err := modified.Status(ctx)
+	assert.NoError(t, err)
+	assert.False(t, status[0].Modified)
+	assert.True(t, status[1].Modified)
+	assert.EqualValues(t, 2, countCourses(t, ctx))
+}
+
+func TestMigrateConcurrent(t *testing.T) {
+	initPG()
+	defer client.Close()
+
+	ctx := context.Background()
+	m := newMigrator(t, ctx, migrateFS)
+
+	// the runners are serialized by the advisory lock, so every migration is
+	// applied once
+	var wg sync.WaitGroup
+	applied := make([][]*migrate.Migration, 2)
+	errs := make([]error, 2)
+	for i := range applied {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			applied[i], errs[i] = m.Up(ctx, 0)
+		}()
+	}
+	wg.Wait()
+
+	assert.NoError(t, errs[0])
+	assert.NoError(t, errs[1])
+	assert.EqualValues(t, 2, len(applied[0])+len(applied[1]))
+	assert.EqualValues(t, 2, countCourses(t, ctx))
+
+	var versions int64
+	err := client.QueryRow(ctx, "SELECT COUNT(*) FROM "+migrateTable).Scan(&versions)
+	assert.NoError(t, err)
+	assert.EqualValues(t, 2, versions)
+}
@@ -0,0 +1,79 @@
+package pgdb_test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/secure-for-ai/secureai-microsvs/db/pggen"
+	"github.com/secure-for-ai/secureai-microsvs/db/sqlBuilderV3"
+	"github.com/stretchr/testify/assert"
+)
+
+// testDoc is the struct generated by pggen for the table test_doc
+type testDoc struct {
+	ID   int64           `db:"id,pk,readonly" json:"id"`
+	Body json.RawMessage `db:"body" json:"body"`
+	Tags []string        `db:"tags" json:"tags"`
+}
+
+func (testDoc) GetTableName() string {
+	return "test_doc"
+}
+
+func TestPGGen(t *testing.T) {
+	initPG()
+	defer client.Close()
+
+	ctx := context.Background()
+	_, err := client.Exec(ctx, "DROP TABLE IF EXISTS test_doc")
+	assert.NoError(t, err)
+	_, err = client.Exec(ctx, `CREATE TABLE test_doc (
+		id bigint GENERATED ALWAYS AS IDENTITY PRIMARY KEY,
+		body jsonb,
+		tags text[] NOT NULL DEFAULT '{}'
+	)`)
+	assert.NoError(t, err)
+	defer client.Exec(ctx, "DROP TABLE IF EXISTS test_doc")
+
+	tables, err := pggen.LoadTables(ctx, client, pggen.Config{Include: []string{"test_doc"}})
+	assert.NoError(t, err)
+	assert.EqualValues(t, []pggen.Table{{
+		Schema: "public",
+		Name:   "test_doc",
+		Columns: []pggen.Column{
+			{Name: "id", UDTName: "int8", PK: true, ReadOnly: true},
+			{Name: "body", UDTName: "jsonb", Nullable: true},
+			{Name: "tags", UDTName: "_text"},
+		},
+	}}, tables)
+
+	src, err := pggen.Generate(tables, pggen.Config{Package: "model"})
+	assert.NoError(t, err)
+	assert.Contains(t, string(src), "\tBody json.RawMessage `db:\"body\" json:\"body\"`\n")
+
+	// the JSON of any kind is scanned into json.RawMessage
+	docs := []testDoc{
+		{Body: json.RawMessage(`{"a": 1}`), Tags: []string{"x"}},
+		{Body: json.RawMessage(`[1, 2]`), Tags: []string{}},
+		{Body: json.RawMessage(`"s"`), Tags: []string{}},
+		{Tags: []string{}},
+	}
+	conn, err := client.GetConn(ctx)
+	assert.NoError(t, err)
+	defer conn.Release()
+	_, err = sqlBuilderV3.InsertBulk(docs).ExecPG(conn, ctx)
+	assert.NoError(t, err)
+
+	got, err := sqlBuilderV3.QueryAll[testDoc](ctx, conn, sqlBuilderV3.Select(&testDoc{}).Asc("id"))
+	assert.NoError(t, err)
+	assert.Len(t, got, len(docs))
+	for i, doc := range got {
+		if docs[i].Body == nil {
+			assert.Nil(t, doc.Body)
+		} else {
+			assert.JSONEq(t, string(docs[i].Body), string(doc.Body))
+		}
+		assert.EqualValues(t, docs[i].Tags, doc.Tags)
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"github.com/secure-for-ai/secureai-microsvs/db/pgdb"
 	"github.com/secure-for-ai/secureai-microsvs/db/sqlBuilderV3"
+	"github.com/secure-for-ai/secureai-microsvs/util"
 	"github.com/stretchr/testify/assert"
 	"os"
 	"testing"
@@ -226,3 +227,461 @@ func TestPGTx(t *testing.T) {
 	err = tx.Commit(ctx)
 	assert.NoError(t, err)
 }
+
+func TestPGReturning(t *testing.T) {
+	initPG()
+	defer client.Close()
+
+	exStu := student{
+		10001,
+		"Carol",
+		"Car",
+		"car@gmail.com",
+		ts.Unix(),
+		ts.Unix(),
+	}
+	exStuList := []student{exStu, exStu}
+	exStuList[0].Uid, exStuList[0].Username = 10002, "Dave"
+	exStuList[1].Uid, exStuList[1].Username = 10003, "Eve"
+	reStu := student{}
+	var reStuSlice []student
+	ctx := context.Background()
+	tx, err := client.Begin(ctx)
+
+	if err != nil {
+		panic("cannot start a transaction")
+	}
+	defer tx.RollBackDefer(ctx)
+
+	affectedRow, err := sqlBuilderV3.Insert(&exStu).Returning(&exStu).ExecPG(tx, ctx, &reStu)
+	assert.NoError(t, err)
+	assert.EqualValues(t, 1, affectedRow)
+	assert.EqualValues(t, exStu, reStu)
+
+	// batched multi-row insert collects every returned row
+	affectedRow, err = sqlBuilderV3.InsertBulk(exStuList).Returning(&exStu).ExecPG(tx, ctx, &reStuSlice)
+	assert.NoError(t, err)
+	assert.EqualValues(t, 2, affectedRow)
+	assert.EqualValues(t, exStuList, reStuSlice)
+
+	exStu.Nickname = "Carl"
+	affectedRow, err = sqlBuilderV3.Update(&exStu).Where(sqlBuilderV3.Map{"uid": exStu.Uid}).Returning(&exStu).ExecPG(tx, ctx, &reStu)
+	assert.NoError(t, err)
+	assert.EqualValues(t, 1, affectedRow)
+	assert.EqualValues(t, exStu, reStu)
+
+	reStuSlice = reStuSlice[:0]
+	affectedRow, err = sqlBuilderV3.Delete(&exStu).Where("uid >= ??", exStu.Uid).Returning(&exStu).ExecPG(tx, ctx, &reStuSlice)
+	assert.NoError(t, err)
+	assert.EqualValues(t, 3, affectedRow)
+	assert.EqualValues(t, 3, len(reStuSlice))
+}
+
+func TestPGReturningError(t *testing.T) {
+	initPG()
+	defer client.Close()
+
+	exStu := student{
+		10006,
+		"Hank",
+		"Han",
+		"han@gmail.com",
+		ts.Unix(),
+		ts.Unix(),
+	}
+	ctx := context.Background()
+
+	// the duplicate key of every RETURNING path is reported, rather than 0 rows
+	for _, result := range []any{&student{}, &[]student{}, &[]map[string]any{}, &[][]any{}} {
+		tx, err := client.Begin(ctx)
+		if err != nil {
+			panic("cannot start a transaction")
+		}
+
+		_, err = sqlBuilderV3.Insert(&exStu).ExecPG(tx, ctx)
+		assert.NoError(t, err)
+		affectedRow, err := sqlBuilderV3.Insert(&exStu).Returning(&exStu).ExecPG(tx, ctx, result)
+		assert.Error(t, err)
+		assert.EqualValues(t, 0, affectedRow)
+		tx.RollBackDefer(ctx)
+	}
+
+	// the batched insert reports the error of the duplicate row
+	tx, err := client.Begin(ctx)
+	if err != nil {
+		panic("cannot start a transaction")
+	}
+	defer tx.RollBackDefer(ctx)
+
+	var reStuSlice []student
+	_, err = sqlBuilderV3.InsertBulk([]student{exStu, exStu}).Returning(&exStu).ExecPG(tx, ctx, &reStuSlice)
+	assert.Error(t, err)
+}
+
+func TestPGCompound(t *testing.T) {
+	initPG()
+	defer client.Close()
+
+	exStuList := []student{
+		{10004, "Frank", "Fra", "fra@gmail.com", ts.Unix(), ts.Unix()},
+		{10005, "Grace", "Gra", "gra@gmail.com", ts.Unix(), ts.Unix()},
+	}
+	var reStuSlice []student
+	var resMaps []map[string]any
+	var resArr [][]any
+	ctx := context.Background()
+	tx, err := client.Begin(ctx)
+
+	if err != nil {
+		panic("cannot start a transaction")
+	}
+	defer tx.RollBackDefer(ctx)
+
+	affectedRow, err := sqlBuilderV3.InsertBulk(exStuList).ExecPG(tx, ctx)
+	assert.NoError(t, err)
+	assert.EqualValues(t, 2, affectedRow)
+
+	newStmt := func() *sqlBuilderV3.Stmt {
+		return sqlBuilderV3.Union(
+			sqlBuilderV3.Select(&exStuList[0]).Where(sqlBuilderV3.Map{"uid": exStuList[0].Uid}),
+			sqlBuilderV3.Select(&exStuList[1]).Where(sqlBuilderV3.Map{"uid": exStuList[1].Uid}),
+		).Asc("uid").Limit(10)
+	}
+
+	affectedRow, err = newStmt().ExecPG(tx, ctx, &reStuSlice)
+	assert.NoError(t, err)
+	assert.EqualValues(t, 2, affectedRow)
+	assert.EqualValues(t, exStuList, reStuSlice)
+
+	affectedRow, err = newStmt().ExecPG(tx, ctx, &resMaps)
+	assert.NoError(t, err)
+	assert.EqualValues(t, 2, affectedRow)
+	assert.EqualValues(t, "Grace", resMaps[1]["username"])
+
+	affectedRow, err = newStmt().ExecPG(tx, ctx, &resArr)
+	assert.NoError(t, err)
+	assert.EqualValues(t, 2, affectedRow)
+	assert.EqualValues(t, exStuList[0].Uid, resArr[0][0])
+}
+
+func TestPGCopy(t *testing.T) {
+	initPG()
+	defer client.Close()
+
+	threshold := sqlBuilderV3.CopyThreshold
+	sqlBuilderV3.CopyThreshold = 2
+	defer func() { sqlBuilderV3.CopyThreshold = threshold }()
+
+	exStuList := []student{
+		{10006, "Heidi", "Hei", "hei@gmail.com", ts.Unix(), ts.Unix()},
+		{10007, "Ivan", "Iva", "iva@gmail.com", ts.Unix(), ts.Unix()},
+		{10008, "Judy", "Jud", "jud@gmail.com", ts.Unix(), ts.Unix()},
+	}
+	var reStuSlice []student
+	ctx := context.Background()
+	tx, err := client.Begin(ctx)
+
+	if err != nil {
+		panic("cannot start a transaction")
+	}
+	defer tx.RollBackDefer(ctx)
+
+	// plain values are inserted through COPY
+	affectedRow, err := sqlBuilderV3.InsertBulk(exStuList[:2]).ExecPG(tx, ctx)
+	assert.NoError(t, err)
+	assert.EqualValues(t, 2, affectedRow)
+
+	// the rows having expressions fall back to the batch
+	affectedRow, err = sqlBuilderV3.Insert().IntoTable("student").IntoColumns(&exStuList[2]).Values(
+		[]any{exStuList[2].Uid, exStuList[2].Username, exStuList[2].Nickname, exStuList[2].Email,
+			sqlBuilderV3.Expr("??::bigint", exStuList[2].CreateTime), exStuList[2].UpdateTime},
+		[]any{int64(10009), "Kim", "Kim", "kim@gmail.com", ts.Unix(), ts.Unix()},
+	).ExecPG(tx, ctx)
+	assert.NoError(t, err)
+	assert.EqualValues(t, 2, affectedRow)
+
+	affectedRow, err = sqlBuilderV3.Select(&exStuList[0]).Where("uid BETWEEN ?? AND ??", 10006, 10008).
+		Asc("uid").ExecPG(tx, ctx, &reStuSlice)
+	assert.NoError(t, err)
+	assert.EqualValues(t, 3, affectedRow)
+	assert.EqualValues(t, exStuList, reStuSlice)
+
+	// the unquoted names are folded to lower case like the insertion
+	affectedRow, err = sqlBuilderV3.Insert().IntoTable("public.Student").
+		IntoColumns("UID", "Username", "nickname", "Email", "create_time", "update_time").Values(
+		[]any{int64(10030), "Quinn", "Qui", "qui@gmail.com", ts.Unix(), ts.Unix()},
+		[]any{int64(10031), "Rita", "Rit", "rit@gmail.com", ts.Unix(), ts.Unix()},
+	).ExecPG(tx, ctx)
+	assert.NoError(t, err)
+	assert.EqualValues(t, 2, affectedRow)
+
+	// COPY fails as a whole on the duplicated key
+	affectedRow, err = sqlBuilderV3.InsertBulk(exStuList[1:]).ExecPG(tx, ctx)
+	assert.Error(t, err)
+	assert.IsType(t, util.MultiError{}, err)
+	assert.EqualValues(t, 0, affectedRow)
+}
+
+func TestPGMultiRow(t *testing.T) {
+	initPG()
+	defer client.Close()
+
+	exStuList := []student{
+		{10010, "Leo", "Leo", "leo@gmail.com", ts.Unix(), ts.Unix()},
+		{10011, "Mia", "Mia", "mia@gmail.com", ts.Unix(), ts.Unix()},
+		{10012, "Ned", "Ned", "ned@gmail.com", ts.Unix(), ts.Unix()},
+	}
+	var reStuSlice []student
+	ctx := context.Background()
+	tx, err := client.Begin(ctx)
+
+	if err != nil {
+		panic("cannot start a transaction")
+	}
+	defer tx.RollBackDefer(ctx)
+
+	// split the rows into chunks of a single row
+	budget := sqlBuilderV3.ChunkByteBudget
+	sqlBuilderV3.ChunkByteBudget = 200
+	defer func() { sqlBuilderV3.ChunkByteBudget = budget }()
+
+	affectedRow, err := sqlBuilderV3.InsertBulk(exStuList).MultiRow().Returning(&exStuList[0]).
+		ExecPG(tx, ctx, &reStuSlice)
+	assert.NoError(t, err)
+	assert.EqualValues(t, 3, affectedRow)
+	assert.EqualValues(t, exStuList, reStuSlice)
+}
+
+func TestPGQuery(t *testing.T) {
+	initPG()
+	defer client.Close()
+
+	exStuList := []student{
+		{10020, "Oli", "Oli", "oli@gmail.com", ts.Unix(), ts.Unix()},
+		{10021, "Pam", "Pam", "pam@gmail.com", ts.Unix(), ts.Unix()},
+	}
+	ctx := context.Background()
+	tx, err := client.Begin(ctx)
+
+	if err != nil {
+		panic("cannot start a transaction")
+	}
+	defer tx.RollBackDefer(ctx)
+
+	_, err = sqlBuilderV3.InsertBulk(exStuList).ExecPG(tx, ctx)
+	assert.NoError(t, err)
+
+	newStmt := func(cols ...string) *sqlBuilderV3.Stmt {
+		stmt := sqlBuilderV3.Select(&exStuList[0])
+		if len(cols) > 0 {
+			stmt = sqlBuilderV3.Select().SelectColumns(cols).From("student")
+		}
+		return stmt.Where("uid >= ??", exStuList[0].Uid).Asc("uid").Limit(10)
+	}
+
+	reStuSlice, err := sqlBuilderV3.QueryAll[student](ctx, tx, newStmt())
+	assert.NoError(t, err)
+	assert.EqualValues(t, exStuList, reStuSlice)
+	assert.EqualValues(t, 10, cap(reStuSlice))
+
+	uids, err := sqlBuilderV3.QueryAll[int64](ctx, tx, newStmt("uid"))
+	assert.NoError(t, err)
+	assert.EqualValues(t, []int64{10020, 10021}, uids)
+
+	reStu, err := sqlBuilderV3.QueryOne[student](ctx, tx, newStmt())
+	assert.NoError(t, err)
+	assert.EqualValues(t, exStuList[0], reStu)
+
+	_, err = sqlBuilderV3.QueryOne[student](ctx, tx, newStmt().Where("uid < 0"))
+	assert.ErrorIs(t, err, pgdb.ErrFindNil)
+
+	count, err := sqlBuilderV3.QueryScalar[int64](ctx, tx,
+		sqlBuilderV3.Select().SelectColumns("COUNT(*)").From("student").Where("uid >= ??", exStuList[0].Uid))
+	assert.NoError(t, err)
+	assert.EqualValues(t, 2, count)
+
+	_, err = sqlBuilderV3.QueryScalar[int64](ctx, tx, newStmt("uid").Where("uid < 0"))
+	assert.ErrorIs(t, err, pgdb.ErrFindNil)
+
+	resMaps, err := sqlBuilderV3.QueryMap(ctx, tx, newStmt("uid"))
+	assert.NoError(t, err)
+	assert.EqualValues(t, []map[string]any{{"uid": int64(10020)}, {"uid": int64(10021)}}, resMaps)
+
+	// the capacity is bounded regardless of the limit
+	reStuSlice, err = sqlBuilderV3.QueryAll[student](ctx, tx, newStmt().Limit(1<<40))
+	assert.NoError(t, err)
+	assert.EqualValues(t, 1024, cap(reStuSlice))
+
+	// the column not in the struct is dropped instead of scanned into the first field
+	reStu, err = sqlBuilderV3.QueryOne[student](ctx, tx, newStmt("uid", "'x' AS extra", "username"))
+	assert.NoError(t, err)
+	assert.EqualValues(t, student{Uid: 10020, Username: "Oli"}, reStu)
+	reStuSlice, err = sqlBuilderV3.QueryAll[student](ctx, tx, newStmt("'x' AS extra", "uid"))
+	assert.NoError(t, err)
+	assert.EqualValues(t, []student{{Uid: 10020}, {Uid: 10021}}, reStuSlice)
+
+	// the failed query is not reported as no row, which aborts the transaction
+	_, err = sqlBuilderV3.QueryOne[student](ctx, tx, newStmt().Where("uid / 0 = 1"))
+	assert.ErrorContains(t, err, "division by zero")
+	assert.NotErrorIs(t, err, pgdb.ErrFindNil)
+}
+
+func TestPGIter(t *testing.T) {
+	initPG()
+	defer client.Close()
+
+	exStuList := []student{
+		{10030, "Quin", "Quin", "quin@gmail.com", ts.Unix(), ts.Unix()},
+		{10031, "Rose", "Rose", "rose@gmail.com", ts.Unix(), ts.Unix()},
+		{10032, "Sam", "Sam", "sam@gmail.com", ts.Unix(), ts.Unix()},
+	}
+	ctx := context.Background()
+	tx, err := client.Begin(ctx)
+
+	if err != nil {
+		panic("cannot start a transaction")
+	}
+	defer tx.RollBackDefer(ctx)
+
+	_, err = sqlBuilderV3.InsertBulk(exStuList).ExecPG(tx, ctx)
+	assert.NoError(t, err)
+
+	newStmt := func() *sqlBuilderV3.Stmt {
+		return sqlBuilderV3.Select(&exStuList[0]).Where("uid >= ??", exStuList[0].Uid).Asc("uid")
+	}
+
+	var reStuSlice []student
+	for stu, err := range sqlBuilderV3.IterPG[student](ctx, tx, newStmt()) {
+		assert.NoError(t, err)
+		reStuSlice = append(reStuSlice, stu)
+	}
+	assert.EqualValues(t, exStuList, reStuSlice)
+
+	// the rows are closed on break, so that the connection can be used again
+	var resMaps []map[string]any
+	for m, err := range sqlBuilderV3.IterPG[map[string]any](ctx, tx, newStmt()) {
+		assert.NoError(t, err)
+		resMaps = append(resMaps, m)
+		break
+	}
+	assert.Len(t, resMaps, 1)
+	assert.EqualValues(t, exStuList[0].Uid, resMaps[0]["uid"])
+
+	uids := []int64{}
+	for uid, err := range sqlBuilderV3.IterPG[int64](ctx, tx, sqlBuilderV3.Select().SelectColumns("uid").
+		From("student").Where("uid >= ??", exStuList[0].Uid).Asc("uid")) {
+		assert.NoError(t, err)
+		uids = append(uids, uid)
+	}
+	assert.EqualValues(t, []int64{10030, 10031, 10032}, uids)
+
+	// the error of the canceled context is yielded
+	cancelCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	n := 0
+	for _, err := range sqlBuilderV3.IterPG[student](cancelCtx, tx, newStmt()) {
+		if err != nil {
+			assert.ErrorIs(t, err, context.Canceled)
+			break
+		}
+		n++
+		cancel()
+	}
+	assert.Equal(t, 1, n)
+}
+
+func TestPGStmtCache(t *testing.T) {
+	initPG()
+	defer client.Close()
+
+	ctx := context.Background()
+	conn, err := client.GetConn(ctx)
+	if err != nil {
+		panic("cannot acquire a connection")
+	}
+	defer conn.Release()
+
+	cache := sqlBuilderV3.StmtCacheOf(conn)
+	cache.SetLimits(2, sqlBuilderV3.StmtCacheMaxBytes)
+	defer cache.SetLimits(sqlBuilderV3.StmtCacheSize, sqlBuilderV3.StmtCacheMaxBytes)
+
+	query := func(uid int64) {
+		_, err := sqlBuilderV3.Select(&student{}).Where("uid = ??", uid).ExecPG(conn, ctx)
+		assert.NoError(t, err)
+	}
+	newQuery := func(col string) {
+		_, err := sqlBuilderV3.Select().SelectColumns(col).From("student").Limit(1).ExecPG(conn, ctx)
+		assert.NoError(t, err)
+	}
+
+	before := cache.Stats()
+	query(1)
+	query(2)
+	newQuery("uid")
+	stats := cache.Stats()
+	assert.EqualValues(t, 1, stats.Hits-before.Hits)
+	assert.EqualValues(t, 2, stats.Misses-before.Misses)
+	assert.EqualValues(t, 2, stats.Len)
+
+	// the least recently used statement is deallocated
+	newQuery("username")
+	stats = cache.Stats()
+	assert.EqualValues(t, 1, stats.Evictions-before.Evictions)
+	assert.EqualValues(t, 2, stats.Len)
+
+	prepared, err := sqlBuilderV3.QueryScalar[int64](ctx, conn, sqlBuilderV3.Select().
+		SelectColumns("COUNT(*)").From("pg_prepared_statements").Where("name LIKE 'sqlb_%'"))
+	assert.NoError(t, err)
+	// the count query is cached as well, which evicts one more statement
+	assert.EqualValues(t, 2, prepared)
+}
+
+func TestPGStmtCacheInvalidate(t *testing.T) {
+	initPG()
+	defer client.Close()
+
+	ctx := context.Background()
+	conn, err := client.GetConn(ctx)
+	if err != nil {
+		panic("cannot acquire a connection")
+	}
+	defer conn.Release()
+
+	_, err = conn.Exec(ctx, "CREATE TEMP TABLE stmt_cache (uid int8 PRIMARY KEY, username text)")
+	assert.NoError(t, err)
+	_, err = conn.Exec(ctx, "INSERT INTO stmt_cache VALUES (1, 'alice')")
+	assert.NoError(t, err)
+
+	query := func() ([]map[string]any, error) {
+		return sqlBuilderV3.QueryMap(ctx, conn, sqlBuilderV3.Select("stmt_cache"))
+	}
+	rows, err := query()
+	assert.NoError(t, err)
+	assert.Len(t, rows, 1)
+	cache := sqlBuilderV3.StmtCacheOf(conn)
+
+	// the statement of a failed query is kept, unless its plan is invalidated
+	insert := func() error {
+		_, err := sqlBuilderV3.Insert(sqlBuilderV3.Map{"uid": 1, "username": "bob"}).IntoTable("stmt_cache").ExecPG(conn, ctx)
+		return err
+	}
+	assert.Error(t, insert())
+	before := cache.Stats()
+	assert.Error(t, insert())
+	assert.EqualValues(t, before.Hits+1, cache.Stats().Hits)
+	assert.EqualValues(t, before.Len, cache.Stats().Len)
+
+	// the cached plan fails once the result type is changed
+	_, err = conn.Exec(ctx, "ALTER TABLE stmt_cache ADD COLUMN nickname text")
+	assert.NoError(t, err)
+	_, err = query()
+	assert.ErrorContains(t, err, "cached plan must not change result type")
+	assert.EqualValues(t, before.Len-1, cache.Stats().Len)
+
+	// the statement is prepared again
+	rows, err = query()
+	assert.NoError(t, err)
+	assert.Len(t, rows, 1)
+	assert.Contains(t, rows[0], "nickname")
+	assert.EqualValues(t, before.Len, cache.Stats().Len)
+}
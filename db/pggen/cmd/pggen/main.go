@@ -0,0 +1,83 @@
+// Command pggen generates the Go structs of the tables from the Postgres catalog.
+//
+//	pggen [flags]          write the structs to -out, or stdout if it is empty
+//	pggen [flags] -check   exit with 1 if -out differs from the generated code
+//
+// The password is read from the environment variable PGPASSWORD if the flag
+// -password is not given.
+package main
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/secure-for-ai/secureai-microsvs/db/pgdb"
+	"github.com/secure-for-ai/secureai-microsvs/db/pggen"
+	"github.com/secure-for-ai/secureai-microsvs/log"
+)
+
+// splitList splits the comma separated flag value
+func splitList(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(s, ",")
+}
+
+func main() {
+	conf := pgdb.PGPoolConf{}
+	conf.RegisterFlags(flag.CommandLine)
+	cfg := pggen.Config{}
+	flag.StringVar(&cfg.Schema, "schema", "public", "schema of the tables")
+	flag.StringVar(&cfg.Package, "package", "model", "package name of the generated file")
+	flag.BoolVar(&cfg.PGType, "pgtype", false, "use the pgtype types for the nullable columns instead of pointers")
+	include := flag.String("include", "", "comma separated glob patterns of the tables to generate")
+	exclude := flag.String("exclude", "", "comma separated glob patterns of the tables to skip")
+	out := flag.String("out", "", "output file, stdout if it is empty")
+	check := flag.Bool("check", false, "check the output file is up to date with the schema")
+	flag.Parse()
+	conf.ReadEnv()
+
+	cfg.Include = splitList(*include)
+	cfg.Exclude = splitList(*exclude)
+	if *check && *out == "" {
+		log.Fatalf("-check requires -out\n")
+	}
+
+	client, err := pgdb.NewPGClient(conf)
+	if err != nil {
+		log.Fatalf("cannot connect to postgres: %v\n", err)
+	}
+	tables, err := pggen.LoadTables(context.Background(), client, cfg)
+	client.Close()
+	if err != nil {
+		log.Fatalf("cannot read the catalog: %v\n", err)
+	}
+
+	src, err := pggen.Generate(tables, cfg)
+	if err != nil {
+		log.Fatalf("cannot generate the code: %v\n", err)
+	}
+
+	switch {
+	case *check:
+		old, err := os.ReadFile(*out)
+		if err != nil && !os.IsNotExist(err) {
+			log.Fatalf("%v\n", err)
+		}
+		if !bytes.Equal(old, src) {
+			fmt.Fprintf(os.Stderr, "%s is out of date with the schema %s\n", *out, cfg.Schema)
+			os.Exit(1)
+		}
+	case *out == "":
+		_, _ = os.Stdout.Write(src)
+	default:
+		if err = os.WriteFile(*out, src, 0o644); err != nil {
+			log.Fatalf("%v\n", err)
+		}
+	}
+}
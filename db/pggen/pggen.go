@@ -0,0 +1,280 @@
+// Package pggen generates the Go structs of the tables from the Postgres catalog.
+// The structs carry the db tags understood by sqlBuilderV3, e.g., `db:"uid,pk"`,
+// and implement sqlBuilderV3.Table.
+package pggen
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"go/format"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/secure-for-ai/secureai-microsvs/db/pgdb"
+)
+
+// Config is the option of the generator
+type Config struct {
+	// Schema is the schema of the tables, "public" by default
+	Schema string
+	// Package is the package name of the generated file
+	Package string
+	// Include is the glob patterns of the tables to generate, e.g., "user*".
+	// All the tables are included if it is empty.
+	Include []string
+	// Exclude is the glob patterns of the tables to skip
+	Exclude []string
+	// PGType uses the pgtype types for the nullable columns, e.g., pgtype.Int8,
+	// instead of pointers.
+	PGType bool
+}
+
+func (c *Config) schema() string {
+	if c.Schema == "" {
+		return "public"
+	}
+	return c.Schema
+}
+
+// match checks whether the table is included and not excluded
+func (c *Config) match(table string) bool {
+	matchAny := func(patterns []string) bool {
+		for _, pattern := range patterns {
+			if ok, _ := path.Match(pattern, table); ok {
+				return true
+			}
+		}
+		return false
+	}
+	return (len(c.Include) == 0 || matchAny(c.Include)) && !matchAny(c.Exclude)
+}
+
+// Column is a column of the table in the catalog
+type Column struct {
+	Name string
+	// UDTName is the type name, e.g., "int8", and "_int8" for the array
+	UDTName  string
+	Nullable bool
+	PK       bool
+	// ReadOnly is the generated column or the identity column of GENERATED ALWAYS
+	ReadOnly bool
+}
+
+// Table is a table in the catalog
+type Table struct {
+	Schema  string
+	Name    string
+	Columns []Column
+}
+
+// catalogColumn is a row of the catalog query
+type catalogColumn struct {
+	TableName  string `db:"table_name"`
+	ColumnName string `db:"column_name"`
+	UDTName    string `db:"udt_name"`
+	Nullable   bool   `db:"nullable"`
+	PK         bool   `db:"pk"`
+	ReadOnly   bool   `db:"readonly"`
+}
+
+const catalogSQL = `SELECT c.table_name, c.column_name, c.udt_name,
+	c.is_nullable = 'YES' AS nullable,
+	EXISTS (
+		SELECT 1 FROM pg_catalog.pg_index i
+		JOIN pg_catalog.pg_attribute a ON a.attrelid = i.indrelid AND a.attnum = ANY (i.indkey)
+		WHERE i.indisprimary AND i.indrelid = format('%I.%I', c.table_schema, c.table_name)::regclass
+			AND a.attname = c.column_name
+	) AS pk,
+	c.is_generated = 'ALWAYS' OR coalesce(c.identity_generation = 'ALWAYS', false) AS readonly
+FROM information_schema.columns c
+JOIN information_schema.tables t
+	ON t.table_schema = c.table_schema AND t.table_name = c.table_name AND t.table_type = 'BASE TABLE'
+WHERE c.table_schema = $1
+ORDER BY c.table_name, c.ordinal_position`
+
+// LoadTables reads the tables of the schema matching the filters from the catalog
+func LoadTables(ctx context.Context, client *pgdb.PGClient, cfg Config) ([]Table, error) {
+	conn, err := client.GetConn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Release()
+
+	var rows []catalogColumn
+	if _, err = conn.FindAll(ctx, catalogSQL, &rows, cfg.schema()); err != nil {
+		return nil, err
+	}
+
+	var tables []Table
+	for _, row := range rows {
+		if !cfg.match(row.TableName) {
+			continue
+		}
+		if len(tables) == 0 || tables[len(tables)-1].Name != row.TableName {
+			tables = append(tables, Table{Schema: cfg.schema(), Name: row.TableName})
+		}
+		table := &tables[len(tables)-1]
+		table.Columns = append(table.Columns, Column{
+			Name:     row.ColumnName,
+			UDTName:  row.UDTName,
+			Nullable: row.Nullable,
+			PK:       row.PK,
+			ReadOnly: row.ReadOnly,
+		})
+	}
+	return tables, nil
+}
+
+// goType is the Go type of a Postgres type, and the import path it needs
+type goType struct {
+	name     string
+	nullable string
+	pkg      string
+	nullPkg  string
+}
+
+var (
+	pgtypePkg = "github.com/jackc/pgx/v5/pgtype"
+
+	// the nullable type of the slice and any is itself, as nil is NULL. JSON is kept
+	// raw, as it can be an object, an array or a scalar.
+	goTypes = map[string]goType{
+		"bool":        {"bool", "pgtype.Bool", "", pgtypePkg},
+		"int2":        {"int16", "pgtype.Int2", "", pgtypePkg},
+		"int4":        {"int32", "pgtype.Int4", "", pgtypePkg},
+		"int8":        {"int64", "pgtype.Int8", "", pgtypePkg},
+		"float4":      {"float32", "pgtype.Float4", "", pgtypePkg},
+		"float8":      {"float64", "pgtype.Float8", "", pgtypePkg},
+		"numeric":     {"pgtype.Numeric", "pgtype.Numeric", pgtypePkg, pgtypePkg},
+		"text":        {"string", "pgtype.Text", "", pgtypePkg},
+		"varchar":     {"string", "pgtype.Text", "", pgtypePkg},
+		"bpchar":      {"string", "pgtype.Text", "", pgtypePkg},
+		"citext":      {"string", "pgtype.Text", "", pgtypePkg},
+		"name":        {"string", "pgtype.Text", "", pgtypePkg},
+		"uuid":        {"[16]byte", "pgtype.UUID", "", pgtypePkg},
+		"bytea":       {"[]byte", "[]byte", "", ""},
+		"json":        {"json.RawMessage", "json.RawMessage", "encoding/json", "encoding/json"},
+		"jsonb":       {"json.RawMessage", "json.RawMessage", "encoding/json", "encoding/json"},
+		"inet":        {"net.IP", "net.IP", "net", "net"},
+		"date":        {"time.Time", "pgtype.Date", "time", pgtypePkg},
+		"timestamp":   {"time.Time", "pgtype.Timestamp", "time", pgtypePkg},
+		"timestamptz": {"time.Time", "pgtype.Timestamptz", "time", pgtypePkg},
+		"time":        {"pgtype.Time", "pgtype.Time", pgtypePkg, pgtypePkg},
+		"interval":    {"pgtype.Interval", "pgtype.Interval", pgtypePkg, pgtypePkg},
+	}
+)
+
+// columnType returns the Go type of the column and the package it needs
+func (cfg *Config) columnType(col Column) (string, string) {
+	udt := col.UDTName
+	array := strings.HasPrefix(udt, "_")
+	udt = strings.TrimPrefix(udt, "_")
+
+	t, ok := goTypes[udt]
+	if !ok {
+		return "any", ""
+	}
+	if array {
+		return "[]" + t.name, t.pkg
+	}
+	if !col.Nullable {
+		return t.name, t.pkg
+	}
+	if cfg.PGType || t.nullable == t.name {
+		return t.nullable, t.nullPkg
+	}
+	return "*" + t.name, t.pkg
+}
+
+// initialisms are written in upper case in the Go names, e.g., "uid" to "UID"
+var initialisms = map[string]bool{
+	"api": true, "http": true, "id": true, "ip": true, "json": true, "sid": true,
+	"sql": true, "uid": true, "uri": true, "url": true, "uuid": true,
+}
+
+// goName converts the snake case name into the exported Go name, e.g.,
+// "user_agent" to "UserAgent".
+func goName(name string) string {
+	var b strings.Builder
+	for _, part := range strings.FieldsFunc(name, func(r rune) bool {
+		return r == '_' || r == '-' || r == ' ' || r == '.'
+	}) {
+		if initialisms[strings.ToLower(part)] {
+			b.WriteString(strings.ToUpper(part))
+			continue
+		}
+		b.WriteString(strings.ToUpper(part[:1]))
+		b.WriteString(part[1:])
+	}
+	if b.Len() == 0 || (b.String()[0] >= '0' && b.String()[0] <= '9') {
+		return "T" + b.String()
+	}
+	return b.String()
+}
+
+// jsonName converts the snake case name into the lower camel case, e.g.,
+// "create_time" to "createTime".
+func jsonName(name string) string {
+	parts := strings.Split(name, "_")
+	for i := 1; i < len(parts); i++ {
+		if parts[i] != "" {
+			parts[i] = strings.ToUpper(parts[i][:1]) + parts[i][1:]
+		}
+	}
+	return strings.Join(parts, "")
+}
+
+// Generate writes the Go file of the tables
+func Generate(tables []Table, cfg Config) ([]byte, error) {
+	var body bytes.Buffer
+	imports := make(map[string]bool)
+
+	for _, table := range tables {
+		typeName := goName(table.Name)
+		fmt.Fprintf(&body, "\n// %s is the table %s.%s\n", typeName, table.Schema, table.Name)
+		fmt.Fprintf(&body, "type %s struct {\n", typeName)
+		for _, col := range table.Columns {
+			t, pkg := cfg.columnType(col)
+			if pkg != "" {
+				imports[pkg] = true
+			}
+
+			tag := col.Name
+			if col.PK {
+				tag += ",pk"
+			}
+			if col.ReadOnly {
+				tag += ",readonly"
+			}
+			fmt.Fprintf(&body, "\t%s %s `db:%q json:%q`\n", goName(col.Name), t, tag, jsonName(col.Name))
+		}
+		body.WriteString("}\n")
+
+		tableName := table.Name
+		if table.Schema != "" && table.Schema != "public" {
+			tableName = table.Schema + "." + table.Name
+		}
+		fmt.Fprintf(&body, "\nfunc (%s) GetTableName() string {\n\treturn %q\n}\n", typeName, tableName)
+	}
+
+	var out bytes.Buffer
+	out.WriteString("// Code generated by pggen. DO NOT EDIT.\n\n")
+	fmt.Fprintf(&out, "package %s\n", cfg.Package)
+	if len(imports) > 0 {
+		pkgs := make([]string, 0, len(imports))
+		for pkg := range imports {
+			pkgs = append(pkgs, pkg)
+		}
+		sort.Strings(pkgs)
+		out.WriteString("\nimport (\n")
+		for _, pkg := range pkgs {
+			fmt.Fprintf(&out, "\t%q\n", pkg)
+		}
+		out.WriteString(")\n")
+	}
+	out.Write(body.Bytes())
+
+	return format.Source(out.Bytes())
+}
@@ -0,0 +1,76 @@
+package pggen
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGoName(t *testing.T) {
+	assert.Equal(t, "UserInfo", goName("user_info"))
+	assert.Equal(t, "UID", goName("uid"))
+	assert.Equal(t, "UserID", goName("user_id"))
+	assert.Equal(t, "T2fa", goName("2fa"))
+	assert.Equal(t, "createTime", jsonName("create_time"))
+}
+
+func TestConfig_Match(t *testing.T) {
+	cfg := Config{Include: []string{"user*", "session"}, Exclude: []string{"user_log"}}
+	assert.True(t, cfg.match("user_info"))
+	assert.True(t, cfg.match("session"))
+	assert.False(t, cfg.match("user_log"))
+	assert.False(t, cfg.match("order"))
+
+	cfg = Config{Exclude: []string{"schema_migrations"}}
+	assert.True(t, cfg.match("order"))
+	assert.False(t, cfg.match("schema_migrations"))
+}
+
+func TestGenerate(t *testing.T) {
+	tables := []Table{{
+		Schema: "public",
+		Name:   "user_info",
+		Columns: []Column{
+			{Name: "uid", UDTName: "int8", PK: true, ReadOnly: true},
+			{Name: "username", UDTName: "varchar"},
+			{Name: "nickname", UDTName: "text", Nullable: true},
+			{Name: "tags", UDTName: "_text", Nullable: true},
+			{Name: "data", UDTName: "jsonb", Nullable: true},
+			{Name: "ip", UDTName: "inet"},
+			{Name: "create_time", UDTName: "timestamptz"},
+			{Name: "geom", UDTName: "geometry"},
+		},
+	}, {
+		Schema: "test",
+		Name:   "score",
+		Columns: []Column{
+			{Name: "uid", UDTName: "int8", PK: true},
+			{Name: "score", UDTName: "float8", Nullable: true},
+		},
+	}}
+
+	src, err := Generate(tables, Config{Package: "model"})
+	assert.NoError(t, err)
+	code := string(src)
+	assert.True(t, strings.HasPrefix(code, "// Code generated by pggen. DO NOT EDIT.\n\npackage model\n"))
+	assert.Contains(t, code, "import (\n\t\"encoding/json\"\n\t\"net\"\n\t\"time\"\n)\n")
+	assert.Contains(t, code, "type UserInfo struct {\n")
+	assert.Contains(t, code, "\tUID        int64           `db:\"uid,pk,readonly\" json:\"uid\"`\n")
+	assert.Contains(t, code, "\tNickname   *string         `db:\"nickname\" json:\"nickname\"`\n")
+	assert.Contains(t, code, "\tTags       []string        `db:\"tags\" json:\"tags\"`\n")
+	assert.Contains(t, code, "\tData       json.RawMessage `db:\"data\" json:\"data\"`\n")
+	assert.Contains(t, code, "\tIP         net.IP          `db:\"ip\" json:\"ip\"`\n")
+	assert.Contains(t, code, "\tCreateTime time.Time       `db:\"create_time\" json:\"createTime\"`\n")
+	assert.Contains(t, code, "\tGeom       any             `db:\"geom\" json:\"geom\"`\n")
+	assert.Contains(t, code, "func (UserInfo) GetTableName() string {\n\treturn \"user_info\"\n}\n")
+	assert.Contains(t, code, "\tScore *float64 `db:\"score\" json:\"score\"`\n")
+	assert.Contains(t, code, "func (Score) GetTableName() string {\n\treturn \"test.score\"\n}\n")
+
+	src, err = Generate(tables, Config{Package: "model", PGType: true})
+	assert.NoError(t, err)
+	code = string(src)
+	assert.Contains(t, code, "\t\"github.com/jackc/pgx/v5/pgtype\"\n")
+	assert.Contains(t, code, "\tNickname   pgtype.Text     `db:\"nickname\" json:\"nickname\"`\n")
+	assert.Contains(t, code, "\tScore pgtype.Float8 `db:\"score\" json:\"score\"`\n")
+}
@@ -0,0 +1,9 @@
+package sqlBuilderV3
+
+import "github.com/secure-for-ai/secureai-microsvs/util"
+
+// UnregisterTable removes the table model from the registry, so that the tests
+// registering the model can be repeated.
+func UnregisterTable(model any) {
+	tableRegistry.Delete(util.ReflectValue(model).Type())
+}
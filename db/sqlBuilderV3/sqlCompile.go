@@ -0,0 +1,207 @@
+package sqlBuilderV3
+
+import (
+	"context"
+	"strings"
+
+	"github.com/secure-for-ai/secureai-microsvs/db"
+	"github.com/secure-for-ai/secureai-microsvs/db/pgdb"
+)
+
+// SlotArg is the placeholder of an arg of a compiled statement
+type SlotArg struct {
+	Name string
+}
+
+// Slot creates the placeholder of an arg, which is bound by name or position
+// when the compiled statement is executed, e.g.,
+//
+//	Select(User{}).Where(Map{"uid": Slot("uid")}).Compile(db.SchPG)
+//
+// The slots of the same name share the value, and Postgres binds them to the same "$n".
+func Slot(name string) SlotArg {
+	return SlotArg{Name: name}
+}
+
+// compiledSlot is a slot and the indexes of the args it binds
+type compiledSlot struct {
+	name  string
+	index []int
+}
+
+// CompiledStmt is the SQL of a statement generated once for a dialect, so that the
+// executions only bind the args. It is immutable and safe for concurrent use.
+type CompiledStmt struct {
+	sql     string
+	schema  db.Schema
+	sqlType Type
+	limit   int
+	// returning reports that the statement returns rows by RETURNING
+	returning bool
+	// args is the args of the statement, in which the slots are bound at execution
+	args  []any
+	slots []compiledSlot
+}
+
+// Compile generates the SQL of stmt for the dialect. The values of the args are
+// kept as defaults, and the ones given by Slot are bound by Bind or BindMap. The
+// bulk insertion, which has the args of several rows, cannot be compiled. stmt
+// can be destroyed afterwards.
+func (stmt *Stmt) Compile(schema db.Schema) (*CompiledStmt, error) {
+	w := NewWriter()
+	defer w.Destroy()
+	sql, args, err := stmt.Gen(w, schema)
+	if err != nil {
+		return nil, err
+	}
+	if len(w.BulkArgs()) > 0 {
+		return nil, ErrNotSupportType
+	}
+
+	c := &CompiledStmt{
+		// the sql is held by the reusable writer
+		sql:       strings.Clone(sql),
+		schema:    schema,
+		sqlType:   stmt.sqlType,
+		limit:     stmt.LimitN,
+		returning: len(stmt.ReturningCols) > 0,
+		args:      make([]any, len(args)),
+	}
+	if c.returning && !supportReturning(schema) {
+		// the emulation of RETURNING needs the statement
+		return nil, ErrNotSupportDialectFeature
+	}
+	copy(c.args, args)
+
+	names := make(map[string]int)
+	for i, arg := range c.args {
+		slot, ok := arg.(SlotArg)
+		if !ok {
+			continue
+		}
+		// the unnamed slots are bound by position only
+		if j, ok := names[slot.Name]; ok && slot.Name != "" {
+			c.slots[j].index = append(c.slots[j].index, i)
+			continue
+		}
+		names[slot.Name] = len(c.slots)
+		c.slots = append(c.slots, compiledSlot{name: slot.Name, index: []int{i}})
+	}
+	return c, nil
+}
+
+// SQL returns the generated SQL
+func (c *CompiledStmt) SQL() string {
+	return c.sql
+}
+
+// Schema returns the dialect of the SQL
+func (c *CompiledStmt) Schema() db.Schema {
+	return c.schema
+}
+
+// Bind returns the args in which the slots are bound by values in the order the
+// slots first appear in the SQL.
+func (c *CompiledStmt) Bind(values ...any) ([]any, error) {
+	if len(values) != len(c.slots) {
+		return nil, ErrSlotCount
+	}
+
+	args := make([]any, len(c.args))
+	copy(args, c.args)
+	for i, slot := range c.slots {
+		for _, j := range slot.index {
+			args[j] = values[i]
+		}
+	}
+	return args, nil
+}
+
+// BindMap returns the args in which the slots are bound by the values of their
+// names.
+func (c *CompiledStmt) BindMap(values Map) ([]any, error) {
+	args := make([]any, len(c.args))
+	copy(args, c.args)
+	for _, slot := range c.slots {
+		v, ok := values[slot.name]
+		if !ok {
+			return nil, &SlotError{Name: slot.name}
+		}
+		for _, j := range slot.index {
+			args[j] = v
+		}
+	}
+	return args, nil
+}
+
+// ExecPG executes the statement compiled for Postgres with the args returned
+// by Bind or BindMap. The rows are scanned into result[0] like Stmt.ExecPG.
+func (c *CompiledStmt) ExecPG(tx pgdb.PGQuerier, ctx context.Context, args []any, result ...any) (int64, error) {
+	if c.schema != db.SchPG {
+		return 0, ErrNotSupportDialectFeature
+	}
+
+	analyzeQuery(tx, ctx, c.sql, args...)
+	name, err := prepareSQL(tx, ctx, c.sql)
+	if err != nil {
+		return 0, err
+	}
+
+	switch c.sqlType {
+	case InsertType, DeleteType, UpdateType:
+		if c.returning && len(result) > 0 {
+			return queryPG(tx, ctx, c.limit, result[0], name, args...)
+		}
+		return execStmt(tx, ctx, name, args...)
+	case SelectType, CompoundType:
+		// result is not given, so do nothing
+		if len(result) == 0 {
+			rows, err := queryStmt(tx, ctx, name, args...)
+			if err != nil {
+				return 0, err
+			}
+			rows.Close()
+			return rows.CommandTag().RowsAffected(), rows.Err()
+		}
+		return queryPG(tx, ctx, c.limit, result[0], name, args...)
+	default:
+		return 0, ErrNotSupportType
+	}
+}
+
+// ExecSQL executes the statement through database/sql with the args returned by
+// Bind or BindMap. The rows are scanned into result[0] like Stmt.ExecSQL.
+func (c *CompiledStmt) ExecSQL(tx SQLQuerier, ctx context.Context, args []any, result ...any) (int64, error) {
+	if !supportArray(c.schema) {
+		// args is owned by the caller of Bind, and never shared by the executions
+		if err := jsonArgs(args); err != nil {
+			return 0, err
+		}
+	}
+
+	switch c.sqlType {
+	case InsertType, DeleteType, UpdateType:
+		if c.returning && len(result) > 0 {
+			return querySQL(tx, ctx, c.limit, result[0], c.sql, args...)
+		}
+		return execSQL(tx, ctx, c.sql, args...)
+	case SelectType, CompoundType:
+		// result is not given, so count the rows only
+		if len(result) == 0 {
+			rows, err := tx.QueryContext(ctx, c.sql, args...)
+			if err != nil {
+				return 0, err
+			}
+			defer rows.Close()
+
+			var n int64 = 0
+			for rows.Next() {
+				n++
+			}
+			return n, rows.Err()
+		}
+		return querySQL(tx, ctx, c.limit, result[0], c.sql, args...)
+	default:
+		return 0, ErrNotSupportType
+	}
+}
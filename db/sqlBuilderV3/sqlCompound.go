@@ -0,0 +1,125 @@
+package sqlBuilderV3
+
+import "github.com/secure-for-ai/secureai-microsvs/db"
+
+type setOp int
+
+const (
+	setOpUnion setOp = iota
+	setOpUnionAll
+	setOpIntersect
+	setOpExcept
+)
+
+var setOpStr = [...]string{
+	setOpUnion:     " UNION ",
+	setOpUnionAll:  " UNION ALL ",
+	setOpIntersect: " INTERSECT ",
+	setOpExcept:    " EXCEPT ",
+}
+
+type compoundItem struct {
+	op   setOp
+	stmt *Stmt
+}
+
+// compound creates "stmt op stmt op ..." statement. The op of the first item is ignored.
+func compound(op setOp, stmts ...*Stmt) *Stmt {
+	stmt := SQL()
+	stmt.sqlType = CompoundType
+	for _, s := range stmts {
+		if s != nil {
+			stmt.compound = append(stmt.compound, compoundItem{op, s})
+		}
+	}
+	return stmt
+}
+
+// Union generate "stmt UNION stmt ..." statement
+func Union(stmts ...*Stmt) *Stmt {
+	return compound(setOpUnion, stmts...)
+}
+
+// UnionAll generate "stmt UNION ALL stmt ..." statement
+func UnionAll(stmts ...*Stmt) *Stmt {
+	return compound(setOpUnionAll, stmts...)
+}
+
+// Intersect generate "stmt INTERSECT stmt ..." statement
+func Intersect(stmts ...*Stmt) *Stmt {
+	return compound(setOpIntersect, stmts...)
+}
+
+// Except generate "stmt EXCEPT stmt ..." statement
+func Except(stmts ...*Stmt) *Stmt {
+	return compound(setOpExcept, stmts...)
+}
+
+// setOp combines stmt with others. The result is stmt itself if it is a compound
+// statement with the same op, or a new compound statement holding stmt as its first item.
+// In this way, "a.Union(b).Intersect(c)" means "(a UNION b) INTERSECT c".
+func (stmt *Stmt) setOp(op setOp, stmts ...*Stmt) *Stmt {
+	result := stmt
+	if stmt.sqlType != CompoundType || stmt.compound[len(stmt.compound)-1].op != op ||
+		stmt.OrderByStr.Len() > 0 || stmt.LimitN > 0 {
+		result = compound(op, stmt)
+	}
+
+	for _, s := range stmts {
+		if s != nil {
+			result.compound = append(result.compound, compoundItem{op, s})
+		}
+	}
+	return result
+}
+
+// Union generate "stmt UNION stmts ..." statement. Note that it may return a new statement.
+func (stmt *Stmt) Union(stmts ...*Stmt) *Stmt {
+	return stmt.setOp(setOpUnion, stmts...)
+}
+
+// UnionAll generate "stmt UNION ALL stmts ..." statement. Note that it may return a new statement.
+func (stmt *Stmt) UnionAll(stmts ...*Stmt) *Stmt {
+	return stmt.setOp(setOpUnionAll, stmts...)
+}
+
+// Intersect generate "stmt INTERSECT stmts ..." statement. Note that it may return a new statement.
+func (stmt *Stmt) Intersect(stmts ...*Stmt) *Stmt {
+	return stmt.setOp(setOpIntersect, stmts...)
+}
+
+// Except generate "stmt EXCEPT stmts ..." statement. Note that it may return a new statement.
+func (stmt *Stmt) Except(stmts ...*Stmt) *Stmt {
+	return stmt.setOp(setOpExcept, stmts...)
+}
+
+func (stmt *Stmt) compoundWriteTo(w *Writer) error {
+	if len(stmt.compound) == 0 {
+		return ErrNoTableName
+	}
+
+	for i, item := range stmt.compound {
+		if i > 0 {
+			w.WriteString(setOpStr[item.op])
+		}
+
+		// the nested compound statement and the one having its own
+		// ORDER BY or LIMIT need to be wrapped. SQLite rejects the
+		// parenthesized member, so it is selected from a sub query.
+		s := item.stmt
+		wrap := s.sqlType == CompoundType || s.OrderByStr.Len() > 0 || s.LimitN > 0 || len(s.with) > 0
+		if wrap && w.schema == db.SchSQLite {
+			w.WriteString("SELECT * FROM (")
+		} else if wrap {
+			w.WriteByte('(')
+		}
+		if err := s.WriteTo(w); err != nil {
+			return err
+		}
+		if wrap {
+			w.WriteByte(')')
+		}
+	}
+
+	return stmt.orderLimitWriteTo(w)
+}
@@ -0,0 +1,279 @@
+package sqlBuilderV3
+
+import (
+	"reflect"
+	"sync"
+
+	"github.com/secure-for-ai/secureai-microsvs/db"
+)
+
+type compareOp int
+
+const (
+	opEq compareOp = iota
+	opNeq
+	opGt
+	opGte
+	opLt
+	opLte
+	opLike
+	opILike
+	opIsNull
+	opNotNull
+	opBetween
+	opNotBetween
+	opIn
+	opNotIn
+)
+
+var compareOpStr = [...]string{
+	opEq:         " = ",
+	opNeq:        " <> ",
+	opGt:         " > ",
+	opGte:        " >= ",
+	opLt:         " < ",
+	opLte:        " <= ",
+	opLike:       " LIKE ",
+	opILike:      " ILIKE ",
+	opIsNull:     " IS NULL",
+	opNotNull:    " IS NOT NULL",
+	opBetween:    " BETWEEN ",
+	opNotBetween: " NOT BETWEEN ",
+	opIn:         " IN (",
+	opNotIn:      " NOT IN (",
+}
+
+// condCompare compares a column with its args, e.g., "col = ??", "col IS NULL",
+// "col BETWEEN ?? AND ??" and "col IN (??,??)".
+type condCompare struct {
+	col  string
+	op   compareOp
+	args []any
+	// slice indicates that args[0] is a slice holding the values of IN
+	slice bool
+}
+
+var _ Cond = &condCompare{}
+var condComparePool = sync.Pool{
+	New: func() any {
+		return &condCompare{
+			args: make([]any, 0, 2),
+		}
+	},
+}
+
+func createCondCompare(col string, op compareOp, args ...any) *condCompare {
+	cond := condComparePool.Get().(*condCompare)
+	cond.col = col
+	cond.op = op
+	cond.args = append(cond.args[:0], args...)
+	cond.slice = false
+	return cond
+}
+
+// Eq generates "col = ??" condition
+func Eq(col string, arg any) Cond {
+	return createCondCompare(col, opEq, arg)
+}
+
+// Neq generates "col <> ??" condition
+func Neq(col string, arg any) Cond {
+	return createCondCompare(col, opNeq, arg)
+}
+
+// Gt generates "col > ??" condition
+func Gt(col string, arg any) Cond {
+	return createCondCompare(col, opGt, arg)
+}
+
+// Gte generates "col >= ??" condition
+func Gte(col string, arg any) Cond {
+	return createCondCompare(col, opGte, arg)
+}
+
+// Lt generates "col < ??" condition
+func Lt(col string, arg any) Cond {
+	return createCondCompare(col, opLt, arg)
+}
+
+// Lte generates "col <= ??" condition
+func Lte(col string, arg any) Cond {
+	return createCondCompare(col, opLte, arg)
+}
+
+// Like generates "col LIKE ??" condition
+func Like(col string, pattern any) Cond {
+	return createCondCompare(col, opLike, pattern)
+}
+
+// ILike generates case-insensitive "col ILIKE ??" condition. It is written as
+// "LOWER(col) LIKE LOWER(??)" for MySQL.
+func ILike(col string, pattern any) Cond {
+	return createCondCompare(col, opILike, pattern)
+}
+
+// IsNull generates "col IS NULL" condition
+func IsNull(col string) Cond {
+	return createCondCompare(col, opIsNull)
+}
+
+// NotNull generates "col IS NOT NULL" condition
+func NotNull(col string) Cond {
+	return createCondCompare(col, opNotNull)
+}
+
+// Between generates "col BETWEEN ?? AND ??" condition
+func Between(col string, lower, upper any) Cond {
+	return createCondCompare(col, opBetween, lower, upper)
+}
+
+// NotBetween generates "col NOT BETWEEN ?? AND ??" condition
+func NotBetween(col string, lower, upper any) Cond {
+	return createCondCompare(col, opNotBetween, lower, upper)
+}
+
+// In generates "col IN (??,??,...)" condition. If a single slice is given, it is
+// written as "col = ANY(??)" for Postgres, and expanded to placeholders otherwise.
+// An empty list never matches.
+func In(col string, values ...any) Cond {
+	return createCondIn(col, opIn, values...)
+}
+
+// NotIn generates "col NOT IN (??,??,...)" condition. If a single slice is given, it is
+// written as "col <> ALL(??)" for Postgres, and expanded to placeholders otherwise.
+// An empty list always matches.
+func NotIn(col string, values ...any) Cond {
+	return createCondIn(col, opNotIn, values...)
+}
+
+func createCondIn(col string, op compareOp, values ...any) Cond {
+	cond := createCondCompare(col, op, values...)
+	if len(values) == 1 {
+		switch v := reflect.ValueOf(values[0]); v.Kind() {
+		case reflect.Slice:
+			// []byte is a value rather than a list
+			cond.slice = v.Type().Elem().Kind() != reflect.Uint8
+		case reflect.Array:
+			cond.slice = true
+		}
+	}
+	return cond
+}
+
+func (cond *condCompare) WriteTo(w *Writer) {
+	switch cond.op {
+	case opIsNull, opNotNull:
+		w.WriteIdent(cond.col)
+		w.WriteString(compareOpStr[cond.op])
+	case opBetween, opNotBetween:
+		w.WriteIdent(cond.col)
+		w.WriteString(compareOpStr[cond.op])
+		w.WriteString(db.Para)
+		w.WriteString(" AND ")
+		w.WriteString(db.Para)
+		w.Append(cond.args...)
+	case opIn, opNotIn:
+		cond.inWriteTo(w)
+	case opILike:
+		// ILIKE is Postgres only
+		if w.schema == db.SchMYSQL || w.schema == db.SchSQLite {
+			w.WriteString("LOWER(")
+			w.WriteIdent(cond.col)
+			w.WriteString(") LIKE LOWER(")
+			w.WriteString(db.Para)
+			w.WriteByte(')')
+			w.Append(cond.args...)
+			return
+		}
+		fallthrough
+	default:
+		w.WriteIdent(cond.col)
+		w.WriteString(compareOpStr[cond.op])
+		w.WriteString(db.Para)
+		w.Append(cond.args...)
+	}
+}
+
+func (cond *condCompare) inWriteTo(w *Writer) {
+	if cond.slice {
+		// pass the slice as an array to Postgres
+		if w.schema == db.SchPG {
+			w.WriteIdent(cond.col)
+			if cond.op == opIn {
+				w.WriteString(" = ANY(")
+			} else {
+				w.WriteString(" <> ALL(")
+			}
+			w.WriteString(db.Para)
+			w.WriteByte(')')
+			w.Append(cond.args[0])
+			return
+		}
+
+		v := reflect.ValueOf(cond.args[0])
+		n := v.Len()
+		if n == 0 {
+			cond.emptyInWriteTo(w)
+			return
+		}
+		w.WriteIdent(cond.col)
+		w.WriteString(compareOpStr[cond.op])
+		for i := 0; i < n; i++ {
+			if i > 0 {
+				w.WriteByte(',')
+			}
+			w.WriteString(db.Para)
+			w.Append(v.Index(i).Interface())
+		}
+		w.WriteByte(')')
+		return
+	}
+
+	if len(cond.args) == 0 {
+		cond.emptyInWriteTo(w)
+		return
+	}
+	w.WriteIdent(cond.col)
+	w.WriteString(compareOpStr[cond.op])
+	for i := range cond.args {
+		if i > 0 {
+			w.WriteByte(',')
+		}
+		w.WriteString(db.Para)
+	}
+	w.WriteByte(')')
+	w.Append(cond.args...)
+}
+
+// emptyInWriteTo writes the condition of an empty IN list, which never matches,
+// and the one of an empty NOT IN list, which always matches.
+func (cond *condCompare) emptyInWriteTo(w *Writer) {
+	if cond.op == opIn {
+		w.WriteString("1 = 0")
+	} else {
+		w.WriteString("1 = 1")
+	}
+}
+
+func (cond *condCompare) And(conds ...Cond) Cond {
+	return andOne(cond, conds...)
+}
+
+func (cond *condCompare) Or(conds ...Cond) Cond {
+	return orOne(cond, conds...)
+}
+
+func (cond *condCompare) IsValid() bool {
+	return len(cond.col) > 0
+}
+
+func (cond *condCompare) Reset() {
+	cond.col = ""
+	cond.args = cond.args[:0]
+	cond.slice = false
+}
+
+func (cond *condCompare) Destroy() {
+	cond.Reset()
+	condComparePool.Put(cond)
+}
@@ -1,12 +1,16 @@
 package sqlBuilderV3
 
 import (
+	"strings"
 	"sync"
 
 	"github.com/secure-for-ai/secureai-microsvs/db"
 )
 
 type condExpr struct {
+	// col is the column written ahead of sql by WriteIdent, e.g., the key of Map,
+	// so that it is quoted for the dialect.
+	col  string
 	sql  *stringWriter
 	args []any
 }
@@ -18,10 +22,19 @@ var condExprPool = sync.Pool{
 	},
 }
 
-// Expr generate customize SQL
+// Expr generate customize SQL. The args are bound to the placeholders "??" in order,
+// or the named placeholders ":name" and "@name" are bound by a single Map or struct
+// arg, e.g.,
+//
+//	Expr("name = :name OR nickname = :name", Map{"name": "Alice"})
 func Expr(sql string, args ...any) *condExpr {
 	var expr = condExprPool.Get().(*condExpr)
 	expr.sql = bufPool.Get().(*stringWriter)
+	if len(args) == 1 && !strings.Contains(sql, db.Para) {
+		if named, namedArgs, ok := bindNamed(sql, args[0]); ok {
+			sql, args = named, namedArgs
+		}
+	}
 	expr.set(sql, args...)
 	return expr
 }
@@ -39,6 +52,9 @@ func (expr *condExpr) set(sql string, args ...any) {
 }
 
 func (expr *condExpr) WriteTo(w *Writer) {
+	if len(expr.col) > 0 {
+		w.WriteIdent(expr.col)
+	}
 	w.WriteString(expr.String())
 	w.Append(expr.args...)
 }
@@ -56,11 +72,13 @@ func (expr *condExpr) IsValid() bool {
 }
 
 func (expr *condExpr) Reset() {
+	expr.col = ""
 	expr.sql.Reset()
 	expr.args = expr.args[:0]
 }
 
 func (expr *condExpr) Destroy() {
+	expr.col = ""
 	expr.sql.Destroy()
 	expr.args = expr.args[:0]
 	condExprPool.Put(expr)
@@ -118,6 +136,13 @@ func (expr *condExpr) appendArg(arg any) {
 	expr.args = append(expr.args, arg)
 }
 
+// colEq generates "col = ??" of the key of Map, in which col is quoted for the dialect
+func colEq(col string, arg any) *condExpr {
+	cond := Expr(" = "+db.Para, arg)
+	cond.col = col
+	return cond
+}
+
 func ExprEq(sql string, arg any) *condExpr {
 	cond := Expr(sql, arg)
 	cond.appendSql(" = ")
@@ -125,65 +150,3 @@ func ExprEq(sql string, arg any) *condExpr {
 
 	return cond
 }
-
-func (setCols *condExpr) appendExpr(e *condExpr) {
-	if setCols.IsValid() {
-		setCols.appendSql(",")
-	}
-	setCols.appendSql(e.String())
-	setCols.appendArgs(e.args...)
-}
-
-func (setCols *condExpr) appendEq(col string, arg any) {
-	if setCols.IsValid() {
-		setCols.appendSql(",")
-	}
-	setCols.appendSql(col)
-	setCols.appendSql(" = ")
-	setCols.appendSql(db.Para)
-	setCols.appendArg(arg)
-}
-
-func (setCols *condExpr) appendInc(col string, args ...any) {
-	var para any = 1
-	if len(args) > 0 {
-		para = args[0]
-	}
-
-	if setCols.IsValid() {
-		setCols.appendSql(",")
-	}
-	setCols.appendSql(col)
-	setCols.appendSql(" = ")
-	setCols.appendSql(col)
-	setCols.appendSql(" + ")
-	setCols.appendSql(db.Para)
-	setCols.appendArg(para)
-}
-
-func (setCols *condExpr) appendDec(col string, args ...any) {
-	var para any = 1
-	if len(args) > 0 {
-		para = args[0]
-	}
-
-	if setCols.IsValid() {
-		setCols.appendSql(",")
-	}
-	setCols.appendSql(col)
-	setCols.appendSql(" = ")
-	setCols.appendSql(col)
-	setCols.appendSql(" - ")
-	setCols.appendSql(db.Para)
-	setCols.appendArg(para)
-}
-
-func (setCols *condExpr) appendSet(col string, val string, args ...any) {
-	if setCols.IsValid() {
-		setCols.appendSql(",")
-	}
-	setCols.appendSql(col)
-	setCols.appendSql(" = ")
-	setCols.appendSql(val)
-	setCols.appendArgs(args...)
-}
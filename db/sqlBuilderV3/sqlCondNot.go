@@ -0,0 +1,56 @@
+package sqlBuilderV3
+
+import (
+	"sync"
+)
+
+type condNot struct {
+	cond Cond
+}
+
+var _ Cond = &condNot{}
+var condNotPool = sync.Pool{
+	New: func() any {
+		return new(condNot)
+	},
+}
+
+// Not generates "NOT (cond)" condition
+func Not(cond Cond) Cond {
+	if cond == nil || !cond.IsValid() {
+		return CondEmpty
+	}
+
+	not := condNotPool.Get().(*condNot)
+	not.cond = cond
+	return not
+}
+
+func (not *condNot) WriteTo(w *Writer) {
+	w.WriteString("NOT (")
+	not.cond.WriteTo(w)
+	w.WriteByte(')')
+}
+
+func (not *condNot) And(conds ...Cond) Cond {
+	return andOne(not, conds...)
+}
+
+func (not *condNot) Or(conds ...Cond) Cond {
+	return orOne(not, conds...)
+}
+
+func (not *condNot) IsValid() bool {
+	return not.cond != nil && not.cond.IsValid()
+}
+
+func (not *condNot) Reset() {
+	// like condAnd and condOr, the underlying cond is not destroyed
+	// recursively, as it can be used by other sql as well.
+	not.cond = nil
+}
+
+func (not *condNot) Destroy() {
+	not.Reset()
+	condNotPool.Put(not)
+}
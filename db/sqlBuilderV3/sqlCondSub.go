@@ -0,0 +1,122 @@
+package sqlBuilderV3
+
+import (
+	"sync"
+)
+
+type subOp int
+
+const (
+	subOpIn subOp = iota
+	subOpNotIn
+	subOpExists
+	subOpNotExists
+)
+
+var subOpStr = [...]string{
+	subOpIn:        " IN (",
+	subOpNotIn:     " NOT IN (",
+	subOpExists:    "EXISTS (",
+	subOpNotExists: "NOT EXISTS (",
+}
+
+// condSub embeds a sub statement into the condition, e.g., "col IN (SELECT ...)"
+// and "EXISTS (SELECT ...)". The sub statement is owned by the condition, and
+// the condition is owned by the statement it is added to through Where, And, Or
+// and Having. Thus, it is released by Stmt.Reset or Stmt.Destroy.
+type condSub struct {
+	col  string
+	op   subOp
+	stmt *Stmt
+}
+
+var _ Cond = &condSub{}
+var condSubPool = sync.Pool{
+	New: func() any {
+		return new(condSub)
+	},
+}
+
+func createCondSub(col string, op subOp, stmt *Stmt) Cond {
+	if stmt == nil {
+		return CondEmpty
+	}
+	cond := condSubPool.Get().(*condSub)
+	cond.col = col
+	cond.op = op
+	cond.stmt = stmt
+	return cond
+}
+
+// InSub generates "col IN (stmt)" condition
+func InSub(col string, stmt *Stmt) Cond {
+	return createCondSub(col, subOpIn, stmt)
+}
+
+// NotInSub generates "col NOT IN (stmt)" condition
+func NotInSub(col string, stmt *Stmt) Cond {
+	return createCondSub(col, subOpNotIn, stmt)
+}
+
+// Exists generates "EXISTS (stmt)" condition
+func Exists(stmt *Stmt) Cond {
+	return createCondSub("", subOpExists, stmt)
+}
+
+// NotExists generates "NOT EXISTS (stmt)" condition
+func NotExists(stmt *Stmt) Cond {
+	return createCondSub("", subOpNotExists, stmt)
+}
+
+func (cond *condSub) WriteTo(w *Writer) {
+	w.WriteIdent(cond.col)
+	w.WriteString(subOpStr[cond.op])
+	if err := cond.stmt.WriteTo(w); err != nil {
+		w.setErr(err)
+	}
+	w.WriteByte(')')
+}
+
+func (cond *condSub) And(conds ...Cond) Cond {
+	return andOne(cond, conds...)
+}
+
+func (cond *condSub) Or(conds ...Cond) Cond {
+	return orOne(cond, conds...)
+}
+
+func (cond *condSub) IsValid() bool {
+	return cond.stmt != nil
+}
+
+func (cond *condSub) Reset() {
+	if cond.stmt != nil {
+		cond.stmt.Destroy()
+		cond.stmt = nil
+	}
+	cond.col = ""
+}
+
+func (cond *condSub) Destroy() {
+	cond.Reset()
+	condSubPool.Put(cond)
+}
+
+// refSubConds finds the sub statement conds in the cond tree, and stores them
+// into the ref, so that they are released along with the statement.
+func refSubConds(ref *[]Cond, cond Cond) {
+	switch cond := cond.(type) {
+	case *condSub:
+		*ref = append(*ref, cond)
+	case *condAnd:
+		for _, c := range *cond {
+			refSubConds(ref, c)
+		}
+	case *condOr:
+		for _, c := range *cond {
+			refSubConds(ref, c)
+		}
+	case *condNot:
+		refSubConds(ref, cond.cond)
+	}
+}
@@ -3,6 +3,7 @@ package sqlBuilderV3_test
 import (
 	"testing"
 
+	"github.com/secure-for-ai/secureai-microsvs/db"
 	"github.com/secure-for-ai/secureai-microsvs/db/sqlBuilderV3"
 	"github.com/stretchr/testify/assert"
 )
@@ -134,3 +135,73 @@ func TestComplex(t *testing.T) {
 	assert.EqualValues(t, "(((A < ?) AND (B = ?)) OR ((A < ?) AND (B = ?))) AND ((A < ?) OR (C LIKE ?))", sql)
 	assert.EqualValues(t, []any{1, "hello", 1, "hello", 1, "username"}, args)
 }
+
+func TestCompare(t *testing.T) {
+	eval := func(cond sqlBuilderV3.Cond, expectSQL string, expectArgs []any) {
+		sql, args, err := sqlBuilderV3.CondToSQL(cond, w)
+		assert.NoError(t, err)
+		assert.EqualValues(t, expectSQL, sql)
+		assert.EqualValues(t, expectArgs, args)
+		cond.Destroy()
+	}
+
+	eval(sqlBuilderV3.Eq("A", 1), "A = ??", []any{1})
+	eval(sqlBuilderV3.Neq("A", 1), "A <> ??", []any{1})
+	eval(sqlBuilderV3.Gt("A", 1), "A > ??", []any{1})
+	eval(sqlBuilderV3.Gte("A", 1), "A >= ??", []any{1})
+	eval(sqlBuilderV3.Lt("A", 1), "A < ??", []any{1})
+	eval(sqlBuilderV3.Lte("A", 1), "A <= ??", []any{1})
+	eval(sqlBuilderV3.Like("B", "%hello%"), "B LIKE ??", []any{"%hello%"})
+	eval(sqlBuilderV3.ILike("B", "%hello%"), "B ILIKE ??", []any{"%hello%"})
+	eval(sqlBuilderV3.IsNull("C"), "C IS NULL", []any{})
+	eval(sqlBuilderV3.NotNull("C"), "C IS NOT NULL", []any{})
+	eval(sqlBuilderV3.Between("A", 1, 10), "A BETWEEN ?? AND ??", []any{1, 10})
+	eval(sqlBuilderV3.NotBetween("A", 1, 10), "A NOT BETWEEN ?? AND ??", []any{1, 10})
+	eval(sqlBuilderV3.In("A", 1, 2, 3), "A IN (??,??,??)", []any{1, 2, 3})
+	eval(sqlBuilderV3.In("A", []int{1, 2, 3}), "A IN (??,??,??)", []any{1, 2, 3})
+	eval(sqlBuilderV3.In("A", [2]string{"x", "y"}), "A IN (??,??)", []any{"x", "y"})
+	eval(sqlBuilderV3.In("A", []byte("x")), "A IN (??)", []any{[]byte("x")})
+	eval(sqlBuilderV3.NotIn("A", 1, 2), "A NOT IN (??,??)", []any{1, 2})
+	eval(sqlBuilderV3.NotIn("A", []int64{1, 2}), "A NOT IN (??,??)", []any{int64(1), int64(2)})
+
+	// an empty IN list never matches, and an empty NOT IN list always matches
+	eval(sqlBuilderV3.In("A"), "1 = 0", []any{})
+	eval(sqlBuilderV3.In("A", []int{}), "1 = 0", []any{})
+	eval(sqlBuilderV3.NotIn("A"), "1 = 1", []any{})
+	eval(sqlBuilderV3.NotIn("A", []int{}), "1 = 1", []any{})
+
+	// Not
+	eval(sqlBuilderV3.Not(sqlBuilderV3.Eq("A", 1)), "NOT (A = ??)", []any{1})
+	eval(sqlBuilderV3.Not(cond1.Or(cond2)), "NOT ((A < ?) OR (B = ?))", []any{1, "hello"})
+	assert.EqualValues(t, condNull, sqlBuilderV3.Not(condNull))
+	assert.EqualValues(t, condNull, sqlBuilderV3.Not(nil))
+
+	// compose
+	eval(sqlBuilderV3.Eq("A", 1).And(sqlBuilderV3.In("B", 2, 3)).Or(sqlBuilderV3.IsNull("C")),
+		"(A = ?? AND B IN (??,??)) OR C IS NULL", []any{1, 2, 3})
+}
+
+func TestCompare_Dialect(t *testing.T) {
+	sql, args, err := sqlBuilderV3.Select("student").Where(sqlBuilderV3.In("uid", []int64{1, 2})).
+		And(sqlBuilderV3.NotIn("username", []string{"Alice"})).
+		And(sqlBuilderV3.In("age", 18, 19)).Gen(w, db.SchPG)
+	assert.NoError(t, err)
+	assert.EqualValues(t, "SELECT * FROM student WHERE uid = ANY($1) AND username <> ALL($2) AND age IN ($3,$4)", sql)
+	assert.EqualValues(t, []any{[]int64{1, 2}, []string{"Alice"}, 18, 19}, args)
+
+	sql, args, err = sqlBuilderV3.Select("student").Where(sqlBuilderV3.In("uid", []int64{1, 2})).
+		And(sqlBuilderV3.ILike("username", "al%")).Gen(w, db.SchMYSQL)
+	assert.NoError(t, err)
+	assert.EqualValues(t, "SELECT * FROM `student` WHERE `uid` IN (?,?) AND LOWER(`username`) LIKE LOWER(?)", sql)
+	assert.EqualValues(t, []any{int64(1), int64(2), "al%"}, args)
+
+	sql, _, err = sqlBuilderV3.Select("student").Where(sqlBuilderV3.ILike("username", "al%")).Gen(w, db.SchSQLite)
+	assert.NoError(t, err)
+	assert.EqualValues(t, "SELECT * FROM student WHERE LOWER(username) LIKE LOWER(?)", sql)
+
+	sql, args, err = sqlBuilderV3.Select("student").GroupBy("age").
+		Having(sqlBuilderV3.Gt("COUNT(uid)", 2)).HavingOr(sqlBuilderV3.Not(sqlBuilderV3.Between("age", 18, 20))).Gen(w, db.SchPG)
+	assert.NoError(t, err)
+	assert.EqualValues(t, "SELECT * FROM student GROUP BY age HAVING COUNT(uid) > $1 OR NOT (age BETWEEN $2 AND $3)", sql)
+	assert.EqualValues(t, []any{2, 18, 20}, args)
+}
@@ -0,0 +1,391 @@
+package sqlBuilderV3
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/secure-for-ai/secureai-microsvs/db"
+	"github.com/secure-for-ai/secureai-microsvs/util"
+)
+
+// Cursor is the position of the keyset pagination, i.e., the values of the
+// ORDER BY columns of the last row of a page.
+type Cursor struct {
+	Cols   []string
+	Values []any
+}
+
+// orderItem is the parsed item of ORDER BY. col is the column or the expression
+// without the direction, e.g., "COALESCE(a,b)" of "COALESCE(a,b) DESC".
+type orderItem struct {
+	col   string
+	desc  bool
+	nulls string
+}
+
+// splitList splits the comma separated list at the top level, i.e., the commas in
+// the parentheses and the quoted literals and identifiers are kept, e.g.,
+// "COALESCE(a,b) DESC, uid" to "COALESCE(a,b) DESC" and "uid".
+func splitList(s string) []string {
+	var items []string
+	var quote byte
+	depth, start := 0, 0
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"' || c == '`':
+			quote = c
+		case c == '(':
+			depth++
+		case c == ')':
+			depth--
+		case c == ',' && depth == 0:
+			items = append(items, s[start:i])
+			start = i + 1
+		}
+	}
+	return append(items, s[start:])
+}
+
+// cutWord removes the trailing keyword of s, which is separated by spaces
+func cutWord(s, word string) (string, bool) {
+	n := len(s) - len(word)
+	if n <= 0 || !strings.EqualFold(s[n:], word) || (s[n-1] != ' ' && s[n-1] != '\t' && s[n-1] != '\n') {
+		return s, false
+	}
+	return strings.TrimRight(s[:n], " \t\n"), true
+}
+
+// parseOrder parses "expr [ASC|DESC] [NULLS FIRST|NULLS LAST]"
+func parseOrder(order string) orderItem {
+	s := strings.TrimSpace(order)
+	item := orderItem{}
+	for _, nulls := range [...]string{"FIRST", "LAST"} {
+		if rest, ok := cutWord(s, nulls); ok {
+			if rest, ok = cutWord(rest, "NULLS"); ok {
+				s, item.nulls = rest, nulls
+				break
+			}
+		}
+	}
+	if rest, ok := cutWord(s, "DESC"); ok {
+		s, item.desc = rest, true
+	} else if rest, ok = cutWord(s, "ASC"); ok {
+		s = rest
+	}
+	item.col = s
+	return item
+}
+
+// appendOrders appends the ORDER BY items of OrderBy, each of which may be a list
+func (stmt *Stmt) appendOrders(order []string) {
+	for _, o := range order {
+		for _, item := range splitList(o) {
+			stmt.orders = append(stmt.orders, parseOrder(item))
+		}
+	}
+}
+
+// orderItems returns the ORDER BY items kept by OrderBy, Desc and Asc
+func (stmt *Stmt) orderItems() []orderItem {
+	return stmt.orders
+}
+
+// After generate the keyset condition of the rows after the cursor in the order of
+// OrderBy, Desc and Asc, e.g., "(score,uid) < ($1,$2)" for Desc("score", "uid").
+// The columns of the cursor must match ORDER BY, which should end with a unique
+// column, e.g., the primary key, and the columns should not be NULL.
+func (stmt *Stmt) After(cursor *Cursor) *Stmt {
+	stmt.cursor = cursor
+	stmt.cursorBefore = false
+	return stmt
+}
+
+// Before generate the keyset condition of the rows before the cursor. ORDER BY is
+// reversed, so that LIMIT takes the rows right before the cursor, which come in
+// the reversed order.
+func (stmt *Stmt) Before(cursor *Cursor) *Stmt {
+	stmt.cursor = cursor
+	stmt.cursorBefore = true
+	return stmt
+}
+
+// keysetCond builds the condition of the cursor. A row value comparison is used if all
+// the columns are in the same direction, otherwise, it is expanded, e.g., "a > $1 OR
+// (a = $1 AND b < $2)". The columns are quoted for the dialect schema.
+func (stmt *Stmt) keysetCond(schema db.Schema) (Cond, error) {
+	items := stmt.orderItems()
+	cursor := stmt.cursor
+	if len(items) == 0 || len(items) != len(cursor.Cols) || len(cursor.Cols) != len(cursor.Values) {
+		return nil, ErrInvalidCursor
+	}
+
+	sameDir := true
+	for i, item := range items {
+		if item.col != cursor.Cols[i] {
+			return nil, ErrInvalidCursor
+		}
+		sameDir = sameDir && item.desc == items[0].desc
+	}
+
+	op := func(desc bool) string {
+		if desc != stmt.cursorBefore {
+			return " < "
+		}
+		return " > "
+	}
+
+	cond := Expr("")
+	if len(items) == 1 {
+		cond.sql.writeIdent(items[0].col, schema)
+		cond.appendSql(op(items[0].desc))
+		cond.appendSql(db.Para)
+		cond.args = append(cond.args, cursor.Values...)
+		return cond, nil
+	}
+
+	if sameDir {
+		cond.appendSql("(")
+		for i, item := range items {
+			if i > 0 {
+				cond.appendSql(",")
+			}
+			cond.sql.writeIdent(item.col, schema)
+		}
+		cond.appendSql(")")
+		cond.appendSql(op(items[0].desc))
+		cond.appendSql("(")
+		cond.appendSql(strings.Repeat(db.Para+",", len(items)-1))
+		cond.appendSql(db.Para + ")")
+		cond.args = append(cond.args, cursor.Values...)
+		return cond, nil
+	}
+
+	for i, item := range items {
+		if i > 0 {
+			cond.appendSql(" OR (")
+		}
+		for j := 0; j < i; j++ {
+			cond.sql.writeIdent(items[j].col, schema)
+			cond.appendSql(" = " + db.Para + " AND ")
+			cond.args = append(cond.args, cursor.Values[j])
+		}
+		cond.sql.writeIdent(item.col, schema)
+		cond.appendSql(op(item.desc))
+		cond.appendSql(db.Para)
+		cond.args = append(cond.args, cursor.Values[i])
+		if i > 0 {
+			cond.appendSql(")")
+		}
+	}
+	return cond, nil
+}
+
+// orderByWriteTo writes ORDER BY, which is reversed for Before. The items are
+// written as given unless reversed or quoted for MySQL.
+func (stmt *Stmt) orderByWriteTo(w *Writer) {
+	reverse := stmt.cursor != nil && stmt.cursorBefore
+	if !reverse && w.schema != db.SchMYSQL {
+		w.Write(stmt.OrderByStr.Bytes())
+		return
+	}
+
+	for i, item := range stmt.orderItems() {
+		if i > 0 {
+			w.WriteString(", ")
+		}
+		item.writeTo(w, reverse)
+	}
+}
+
+// writeTo writes the item with the quoted column, and the direction reversed if
+// reverse is true
+func (item orderItem) writeTo(w *Writer, reverse bool) {
+	w.WriteIdent(item.col)
+	if item.desc != reverse {
+		w.WriteString(" DESC")
+	} else {
+		w.WriteString(" ASC")
+	}
+
+	nulls := item.nulls
+	if reverse && nulls == "FIRST" {
+		nulls = "LAST"
+	} else if reverse && nulls == "LAST" {
+		nulls = "FIRST"
+	}
+	if nulls != "" {
+		w.WriteString(" NULLS ")
+		w.WriteString(nulls)
+	}
+}
+
+// CursorOf builds the cursor from the row, which is usually the last scanned row of
+// a page. The row is a struct or a map[string]any, and the value of the ORDER BY column
+// is found by the db tag or the field name without the table qualifier, e.g., "uid"
+// for "S.uid".
+func (stmt *Stmt) CursorOf(row any) (*Cursor, error) {
+	items := stmt.orderItems()
+	if len(items) == 0 {
+		return nil, ErrInvalidCursor
+	}
+
+	cursor := &Cursor{
+		Cols:   make([]string, len(items)),
+		Values: make([]any, len(items)),
+	}
+
+	m, isMap := row.(map[string]any)
+	var v reflect.Value
+	if !isMap {
+		v = util.ReflectValue(row)
+		if v.Kind() != reflect.Struct {
+			return nil, ErrInvalidCursor
+		}
+	}
+
+	for i, item := range items {
+		name := item.col
+		if j := strings.LastIndexByte(name, '.'); j >= 0 {
+			name = name[j+1:]
+		}
+
+		var ok bool
+		if isMap {
+			cursor.Values[i], ok = m[name]
+		} else {
+			cursor.Values[i], ok = structField(v, name)
+		}
+		if !ok {
+			return nil, ErrInvalidCursor
+		}
+		cursor.Cols[i] = item.col
+	}
+	return cursor, nil
+}
+
+// structField finds the field of the column by the db tag or the field name
+func structField(v reflect.Value, col string) (any, bool) {
+	for _, c := range getStructColumns(v.Type()).cols {
+		if c.Name == col && v.Type().Field(c.Index).IsExported() {
+			return v.Field(c.Index).Interface(), true
+		}
+	}
+	return nil, false
+}
+
+// cursorPayload is the JSON of the encoded cursor. The indexes of time.Time values
+// are kept in Times, so that they are decoded as time.Time instead of strings.
+type cursorPayload struct {
+	Cols   []string          `json:"c"`
+	Values []json.RawMessage `json:"v"`
+	Times  []int             `json:"t,omitempty"`
+}
+
+// Encode encodes the cursor into an opaque token, which is signed by HMAC-SHA256
+// with the key. Thus, the token returned to the clients cannot be forged or altered
+// without the key. It returns ErrNoCursorKey if the key is empty.
+func (cursor *Cursor) Encode(key []byte) (string, error) {
+	if len(key) == 0 {
+		return "", ErrNoCursorKey
+	}
+
+	payload := cursorPayload{
+		Cols:   cursor.Cols,
+		Values: make([]json.RawMessage, len(cursor.Values)),
+	}
+	for i, value := range cursor.Values {
+		if t, ok := value.(time.Time); ok {
+			payload.Times = append(payload.Times, i)
+			value = t.Format(time.RFC3339Nano)
+		}
+		b, err := json.Marshal(value)
+		if err != nil {
+			return "", err
+		}
+		payload.Values[i] = b
+	}
+
+	b, err := json.Marshal(payload)
+	if err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(b) + "." +
+		base64.RawURLEncoding.EncodeToString(cursorSign(b, key)), nil
+}
+
+// DecodeCursor verifies and decodes the token of Cursor.Encode. It returns
+// ErrInvalidCursor if the token is malformed or its signature does not match, and
+// ErrNoCursorKey if the key is empty.
+func DecodeCursor(token string, key []byte) (*Cursor, error) {
+	if len(key) == 0 {
+		return nil, ErrNoCursorKey
+	}
+	data, sig, ok := strings.Cut(token, ".")
+	if !ok {
+		return nil, ErrInvalidCursor
+	}
+	b, err := base64.RawURLEncoding.DecodeString(data)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	s, err := base64.RawURLEncoding.DecodeString(sig)
+	if err != nil || !hmac.Equal(s, cursorSign(b, key)) {
+		return nil, ErrInvalidCursor
+	}
+
+	var payload cursorPayload
+	if err = json.Unmarshal(b, &payload); err != nil || len(payload.Cols) != len(payload.Values) {
+		return nil, ErrInvalidCursor
+	}
+
+	cursor := &Cursor{
+		Cols:   payload.Cols,
+		Values: make([]any, len(payload.Values)),
+	}
+	for i, raw := range payload.Values {
+		// keep the integers as int64 instead of float64
+		dec := json.NewDecoder(bytes.NewReader(raw))
+		dec.UseNumber()
+		if err = dec.Decode(&cursor.Values[i]); err != nil {
+			return nil, ErrInvalidCursor
+		}
+		if n, ok := cursor.Values[i].(json.Number); ok {
+			if cursor.Values[i], err = n.Int64(); err != nil {
+				if cursor.Values[i], err = n.Float64(); err != nil {
+					return nil, ErrInvalidCursor
+				}
+			}
+		}
+	}
+	for _, i := range payload.Times {
+		if i < 0 || i >= len(cursor.Values) {
+			return nil, ErrInvalidCursor
+		}
+		s, ok := cursor.Values[i].(string)
+		if !ok {
+			return nil, ErrInvalidCursor
+		}
+		if cursor.Values[i], err = time.Parse(time.RFC3339Nano, s); err != nil {
+			return nil, ErrInvalidCursor
+		}
+	}
+	return cursor, nil
+}
+
+func cursorSign(data, key []byte) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write(data)
+	return mac.Sum(nil)
+}
@@ -0,0 +1,135 @@
+package sqlBuilderV3
+
+import (
+	"strings"
+
+	"github.com/secure-for-ai/secureai-microsvs/db"
+)
+
+// isPlainIdent checks whether name is a plain identifier like "uid", "S.uid" or
+// "S.*", which starts with a letter or an underscore and can be quoted safely.
+// Anything else, e.g., an expression or a name with an alias, is written verbatim.
+func isPlainIdent(name string) bool {
+	if len(name) == 0 {
+		return false
+	}
+
+	start := 0
+	for i := 0; i <= len(name); i++ {
+		if i < len(name) && name[i] != '.' {
+			continue
+		}
+
+		part := name[start:i]
+		switch {
+		case len(part) == 0:
+			return false
+		case part == "*":
+			// the wildcard is only allowed as the last part
+			if i != len(name) || start == 0 {
+				return false
+			}
+		case part[0] != '_' && !('a' <= part[0] && part[0] <= 'z') && !('A' <= part[0] && part[0] <= 'Z'):
+			return false
+		default:
+			for j := 0; j < len(part); j++ {
+				if !isIdentByte(part[j]) {
+					return false
+				}
+			}
+		}
+		start = i + 1
+	}
+	return true
+}
+
+// WriteIdent writes the identifier of a table or a column. The plain identifier
+// is quoted with backticks for MySQL, so that the reserved words, e.g., `order`,
+// can be used as names. Other dialects keep the identifier unquoted, as quoting
+// makes the identifier case-sensitive in Postgres.
+func (w *Writer) WriteIdent(name string) {
+	w.stringWriter.writeIdent(name, w.schema)
+}
+
+// writeIdent writes the identifier like WriteIdent for the buffers built ahead of
+// the writer, e.g., the keyset condition of the cursor.
+func (w *stringWriter) writeIdent(name string, schema db.Schema) {
+	if schema != db.SchMYSQL || !isPlainIdent(name) {
+		w.WriteString(name)
+		return
+	}
+
+	for {
+		i := strings.IndexByte(name, '.')
+		part := name
+		if i >= 0 {
+			part = name[:i]
+		}
+
+		if part == "*" {
+			w.WriteByte('*')
+		} else {
+			w.WriteByte('`')
+			w.WriteString(part)
+			w.WriteByte('`')
+		}
+
+		if i < 0 {
+			return
+		}
+		w.WriteByte('.')
+		name = name[i+1:]
+	}
+}
+
+// QuoteIdent quotes the identifier for the dialect, e.g., "S"."uid" for Postgres
+// and SQLite, and `S`.`uid` for MySQL. The quote characters in the identifier
+// are escaped by doubling, and "*" is kept as it is.
+func QuoteIdent(name string, schema db.Schema) string {
+	quote := byte('"')
+	if schema == db.SchMYSQL {
+		quote = '`'
+	}
+
+	w := bufPool.Get().(*stringWriter)
+	defer w.Destroy()
+	for i, part := range strings.Split(name, ".") {
+		if i > 0 {
+			w.WriteByte('.')
+		}
+		if part == "*" {
+			w.WriteByte('*')
+			continue
+		}
+		w.WriteByte(quote)
+		for j := 0; j < len(part); j++ {
+			if part[j] == quote {
+				w.WriteByte(quote)
+			}
+			w.WriteByte(part[j])
+		}
+		w.WriteByte(quote)
+	}
+	return strings.Clone(w.String())
+}
+
+// JoinIdent writes the identifiers separated by sep
+func (w *Writer) JoinIdent(elems []string, sep byte) {
+	w.WriteIdent(elems[0])
+	for _, s := range elems[1:] {
+		w.WriteByte(sep)
+		w.WriteIdent(s)
+	}
+}
+
+// supportArray checks whether the dialect has array types. Otherwise, the slices,
+// maps and structs are stored as JSON by ExecSQL.
+func supportArray(schema db.Schema) bool {
+	return schema == db.SchPG
+}
+
+// supportReturning checks whether the dialect supports RETURNING. Otherwise,
+// RETURNING is emulated by the executor.
+func supportReturning(schema db.Schema) bool {
+	return schema != db.SchMYSQL
+}
@@ -14,7 +14,11 @@ var (
 	// ErrNoTableName no table name
 	ErrNoTableName = errors.New("No table indicated")
 	// ErrNoColumnToUpdate no column to update
-	//ErrNoColumnToUpdate = errors.New("No column(s) to update")
+	ErrNoColumnToUpdate = errors.New("No column(s) to update")
+	// ErrNoPrimaryKey no primary key or its values indicated
+	ErrNoPrimaryKey = errors.New("No primary key indicated")
+	// ErrNoConflictTarget no conflict target for ON CONFLICT DO UPDATE
+	ErrNoConflictTarget = errors.New("No conflict target indicated")
 	// ErrNoValueToInsert no value to insert
 	ErrNoValueToInsert = errors.New("No value(s) to insert")
 	// ErrNotSupportDialectType not supported dialect type error
@@ -31,8 +35,82 @@ var (
 	//ErrDialectNotSetUp = errors.New("Dialect is not setup yet, try to use `Dialect(dbType)` at first")
 	// ErrInvalidLimitation offset or limit is not correct
 	ErrInvalidLimitation = errors.New("Offset or limit is not correct")
+	// ErrNotSupportDialectFeature the feature is not supported by the dialect
+	ErrNotSupportDialectFeature = errors.New("Not supported feature in the dialect")
+	// ErrNotSupportJoinType join type is not supported by the dialect
+	ErrNotSupportJoinType = errors.New("Not supported join type")
+	// ErrInvalidIdentifier the identifier is rejected in the strict mode
+	ErrInvalidIdentifier = errors.New("Invalid identifier")
+	// ErrDistinctOnOrder the columns of DISTINCT ON do not lead ORDER BY
+	ErrDistinctOnOrder = errors.New("DISTINCT ON columns must lead ORDER BY")
+	// ErrNoLockStrength OF, SKIP LOCKED or NOWAIT without FOR UPDATE or FOR SHARE
+	ErrNoLockStrength = errors.New("No lock strength indicated")
+	// ErrInvalidCursor the cursor is malformed, forged or does not match ORDER BY
+	ErrInvalidCursor = errors.New("Invalid cursor")
+	// ErrNoCursorKey the key to sign or verify the cursor is empty
+	ErrNoCursorKey = errors.New("No cursor key indicated")
+	// ErrWindowShared the window definition is added to more than one statement or expression
+	ErrWindowShared = errors.New("Window definition already added")
+	// ErrUnboundSlot the slot of a compiled statement is not given a value
+	ErrUnboundSlot = errors.New("Slot not bound")
+	// ErrSlotCount the number of the values does not match the slots
+	ErrSlotCount = errors.New("Number of slot values mismatch")
+	// ErrUnboundParam the named parameter is not found in the given Map or struct
+	ErrUnboundParam = errors.New("Named parameter not bound")
+	// ErrNotTableTarget the target of UPDATE or DELETE is a sub statement rather than a table
+	ErrNotTableTarget = errors.New("Target of UPDATE or DELETE must be a table")
+	// ErrTableRegistered the table model is already registered under another name
+	ErrTableRegistered = errors.New("Table model registered under another name")
 	// ErrUnnamedDerivedTable Every derived table must have its own alias
 	//ErrUnnamedDerivedTable = errors.New("Every derived table must have its own alias")
 	// ErrInconsistentDialect Inconsistent dialect in same builder
 	//ErrInconsistentDialect = errors.New("Inconsistent dialect in same builder")
 )
+
+// IdentError is returned by Gen in the strict mode, if the identifier does not
+// match the safe pattern or is not in the column allowlist. It matches
+// ErrInvalidIdentifier with errors.Is.
+type IdentError struct {
+	Ident string
+	// NotAllowed reports that the column is not in the allowlist
+	NotAllowed bool
+}
+
+func (e *IdentError) Error() string {
+	if e.NotAllowed {
+		return "Column not allowed: " + e.Ident
+	}
+	return "Invalid identifier: " + e.Ident
+}
+
+func (e *IdentError) Is(target error) bool {
+	return target == ErrInvalidIdentifier
+}
+
+// SlotError is returned by CompiledStmt.BindMap if the named slot is not given a
+// value. It matches ErrUnboundSlot with errors.Is.
+type SlotError struct {
+	Name string
+}
+
+func (e *SlotError) Error() string {
+	return "Slot not bound: " + e.Name
+}
+
+func (e *SlotError) Is(target error) bool {
+	return target == ErrUnboundSlot
+}
+
+// ParamError is returned by Gen if the named parameter, e.g., ":uid", is not found
+// in the given Map or struct. It matches ErrUnboundParam with errors.Is.
+type ParamError struct {
+	Name string
+}
+
+func (e *ParamError) Error() string {
+	return "Named parameter not bound: " + e.Name
+}
+
+func (e *ParamError) Is(target error) bool {
+	return target == ErrUnboundParam
+}
@@ -0,0 +1,101 @@
+package sqlBuilderV3
+
+import (
+	"context"
+	"iter"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/secure-for-ai/secureai-microsvs/db/pgdb"
+)
+
+// rowScanner returns the function scanning the current row of rows into v,
+// which is a struct mapped by the db tags, map[string]any, []any, or a type
+// scanned from the single column.
+func rowScanner[T any](rows pgx.Rows, v *T) (func() error, error) {
+	switch dest := any(v).(type) {
+	case *map[string]any:
+		fields := rows.FieldDescriptions()
+		return func() error {
+			values, err := rows.Values()
+			if err != nil {
+				return err
+			}
+			// a new map for every row, as the map is kept by the caller
+			m := make(map[string]any, len(fields))
+			for i := range fields {
+				m[fields[i].Name] = values[i]
+			}
+			*dest = m
+			return nil
+		}, nil
+	case *[]any:
+		return func() (err error) {
+			*dest, err = rows.Values()
+			return err
+		}, nil
+	}
+
+	if isStruct[T]() {
+		args, err := pgdb.StructScanArgs(rows, v)
+		if err != nil {
+			return nil, err
+		}
+		return func() error {
+			return rows.Scan(args...)
+		}, nil
+	}
+	return func() error {
+		return rows.Scan(v)
+	}, nil
+}
+
+// IterPG runs the query and iterates over the rows one at a time, so that a large
+// result is never buffered in memory, e.g.,
+//
+//	for user, err := range IterPG[User](ctx, conn, Select(User{})) {
+//		if err != nil {
+//			return err
+//		}
+//		...
+//	}
+//
+// The struct T is reused to scan every row. The rows are closed when the loop
+// ends, breaks or ctx is canceled. The error, if any, is yielded at last with
+// the zero value of T.
+func IterPG[T any](ctx context.Context, q pgdb.PGQuerier, stmt *Stmt) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		var v, zero T
+		rows, err := queryRows(ctx, q, stmt)
+		if err != nil {
+			yield(zero, err)
+			return
+		}
+		defer rows.Close()
+
+		scan, err := rowScanner(rows, &v)
+		if err != nil {
+			yield(zero, err)
+			return
+		}
+
+		for rows.Next() {
+			// the rows already received are not interrupted by ctx
+			if err = ctx.Err(); err != nil {
+				yield(zero, err)
+				return
+			}
+			if err = scan(); err != nil {
+				yield(zero, err)
+				return
+			}
+			if !yield(v, nil) {
+				return
+			}
+		}
+
+		rows.Close()
+		if err = rows.Err(); err != nil {
+			yield(zero, err)
+		}
+	}
+}
@@ -0,0 +1,119 @@
+package sqlBuilderV3
+
+import (
+	"github.com/secure-for-ai/secureai-microsvs/db"
+)
+
+type lockStrength int
+
+const (
+	lockNone lockStrength = iota
+	lockUpdate
+	lockNoKeyUpdate
+	lockShare
+)
+
+var lockStrengthStr = []string{
+	lockUpdate:      " FOR UPDATE",
+	lockNoKeyUpdate: " FOR NO KEY UPDATE",
+	lockShare:       " FOR SHARE",
+}
+
+type lockWait int
+
+const (
+	lockWaitDefault lockWait = iota
+	lockSkipLocked
+	lockNoWait
+)
+
+// rowLock is the locking clause of the select statement
+type rowLock struct {
+	strength lockStrength
+	of       []string
+	wait     lockWait
+}
+
+func (l *rowLock) init() {
+	l.strength = lockNone
+	l.of = []string{}
+	l.wait = lockWaitDefault
+}
+
+func (l *rowLock) reset() {
+	l.strength = lockNone
+	l.of = l.of[:0]
+	l.wait = lockWaitDefault
+}
+
+// ForUpdate generate "FOR UPDATE", which locks the selected rows against
+// concurrent updates and deletions until the end of the transaction.
+func (stmt *Stmt) ForUpdate() *Stmt {
+	stmt.lock.strength = lockUpdate
+	return stmt
+}
+
+// ForNoKeyUpdate generate "FOR NO KEY UPDATE", which is weaker than FOR UPDATE
+// and does not block the insertion referring the rows by foreign keys. It is
+// only supported by Postgres.
+func (stmt *Stmt) ForNoKeyUpdate() *Stmt {
+	stmt.lock.strength = lockNoKeyUpdate
+	return stmt
+}
+
+// ForShare generate "FOR SHARE", which blocks the concurrent updates but not
+// the other FOR SHARE.
+func (stmt *Stmt) ForShare() *Stmt {
+	stmt.lock.strength = lockShare
+	return stmt
+}
+
+// Of generate "FOR UPDATE OF tables", which only locks the rows of the tables
+// or aliases in the join.
+func (stmt *Stmt) Of(tables ...string) *Stmt {
+	stmt.lock.of = append(stmt.lock.of, tables...)
+	return stmt
+}
+
+// SkipLocked generate "SKIP LOCKED", which skips the rows locked by others
+// instead of waiting, e.g., for the consumers of a work queue.
+func (stmt *Stmt) SkipLocked() *Stmt {
+	stmt.lock.wait = lockSkipLocked
+	return stmt
+}
+
+// NoWait generate "NOWAIT", which fails instead of waiting for the rows locked
+// by others.
+func (stmt *Stmt) NoWait() *Stmt {
+	stmt.lock.wait = lockNoWait
+	return stmt
+}
+
+// lockWriteTo writes the locking clause after LIMIT. SQLite has no row locks,
+// and MySQL has no FOR NO KEY UPDATE.
+func (stmt *Stmt) lockWriteTo(w *Writer) error {
+	if stmt.lock.strength == lockNone {
+		if len(stmt.lock.of) > 0 || stmt.lock.wait != lockWaitDefault {
+			return ErrNoLockStrength
+		}
+		return nil
+	}
+
+	if w.schema == db.SchSQLite || (w.schema == db.SchMYSQL && stmt.lock.strength == lockNoKeyUpdate) {
+		return ErrNotSupportDialectFeature
+	}
+
+	w.WriteString(lockStrengthStr[stmt.lock.strength])
+	if len(stmt.lock.of) > 0 {
+		w.WriteString(" OF ")
+		w.JoinIdent(stmt.lock.of, ',')
+	}
+
+	switch stmt.lock.wait {
+	case lockSkipLocked:
+		w.WriteString(" SKIP LOCKED")
+	case lockNoWait:
+		w.WriteString(" NOWAIT")
+	}
+	return nil
+}
@@ -0,0 +1,237 @@
+package sqlBuilderV3
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/secure-for-ai/secureai-microsvs/db"
+	"github.com/secure-for-ai/secureai-microsvs/util"
+)
+
+// namedArg is the value of a named parameter. The repeated placeholders of the
+// same name in an expression share the namedArg, so that Postgres binds them to
+// the same position.
+type namedArg struct {
+	name  string
+	value any
+	bound bool
+}
+
+// namedParams returns the values of the named parameters, which is either a Map
+// or a struct mapped by the db tags. ok is false for the other types, which are
+// bound to "??" instead.
+func namedParams(params any) (values func(name string) (any, bool), ok bool) {
+	switch params := params.(type) {
+	case Map:
+		return func(name string) (any, bool) {
+			v, ok := params[name]
+			return v, ok
+		}, true
+	case map[string]any:
+		return func(name string) (any, bool) {
+			v, ok := params[name]
+			return v, ok
+		}, true
+	}
+
+	v := util.ReflectValue(params)
+	t := v.Type()
+	if t.Kind() != reflect.Struct || t == timeType || reflect.PointerTo(t).Implements(scannerType) ||
+		t.Implements(valuerType) {
+		return nil, false
+	}
+	return func(name string) (any, bool) {
+		return structField(v, name)
+	}, true
+}
+
+func isNameStart(c byte) bool {
+	return c == '_' || ('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z')
+}
+
+func isNameChar(c byte) bool {
+	return isNameStart(c) || ('0' <= c && c <= '9')
+}
+
+// bindNamed rewrites the named placeholders ":name" and "@name" of sql into "??",
+// and returns the args bound from params. The placeholders in the quoted literals
+// and identifiers, and the Postgres casts "::type" are kept as is. ok is false if
+// params is not a Map or a struct, or sql has no named placeholder.
+func bindNamed(sql string, params any) (string, []any, bool) {
+	values, ok := namedParams(params)
+	if !ok {
+		return sql, nil, false
+	}
+
+	var b strings.Builder
+	var args []any
+	var names map[string]*namedArg
+	var quote byte
+	start := 0
+	for i := 0; i < len(sql); i++ {
+		c := sql[i]
+		switch {
+		case quote != 0:
+			// the doubled quote escapes itself, which closes and reopens the literal
+			if c == quote {
+				quote = 0
+			}
+			continue
+		case c == '\'' || c == '"' || c == '`':
+			quote = c
+			continue
+		case c == ':' && i+1 < len(sql) && sql[i+1] == ':':
+			// the cast, e.g., "uid::text"
+			i++
+			continue
+		case c != ':' && c != '@':
+			continue
+		}
+
+		// the placeholder is not a part of a name, e.g., "arr[1:n]" or "@@"
+		if i+1 >= len(sql) || !isNameStart(sql[i+1]) || (i > 0 && (isNameChar(sql[i-1]) || sql[i-1] == '@')) {
+			continue
+		}
+		end := i + 2
+		for end < len(sql) && isNameChar(sql[end]) {
+			end++
+		}
+		name := sql[i+1 : end]
+
+		if names == nil {
+			names = make(map[string]*namedArg)
+			b.Grow(len(sql))
+		}
+		arg, ok := names[name]
+		if !ok {
+			arg = &namedArg{name: name}
+			arg.value, arg.bound = values(name)
+			names[name] = arg
+		}
+		args = append(args, arg)
+
+		b.WriteString(sql[start:i])
+		b.WriteString(db.Para)
+		start = end
+		i = end - 1
+	}
+
+	if names == nil {
+		return sql, nil, false
+	}
+	b.WriteString(sql[start:])
+	return b.String(), args, true
+}
+
+// hasNamedArgs checks whether args has the values of named parameters or the named
+// slots of Compile
+func hasNamedArgs(args []any) bool {
+	for _, arg := range args {
+		switch arg := arg.(type) {
+		case *namedArg:
+			return true
+		case SlotArg:
+			if arg.Name != "" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// genNamed replaces "??" of sql with the placeholders of the dialect like Gen, and
+// binds the named parameters in w.args, or in every row of w.bulkArgs of the bulk
+// insertion. Postgres binds the repeated names of an expression, and the named slots
+// of the same name, to the same "$n", and the other dialects duplicate the args.
+func genNamed(sql string, w *Writer) (string, []any, error) {
+	w.stringWriter.Reset()
+	w.Grow(len(sql))
+
+	var err error
+	if len(w.bulkArgs) == 0 {
+		w.args, err = w.bindNamedArgs(sql, w.args, true)
+		if err != nil {
+			return "", nil, err
+		}
+		return w.String(), w.args, nil
+	}
+
+	// every row has the named args at the same positions, so the sql is written once
+	for i, args := range w.bulkArgs {
+		if *args, err = w.bindNamedArgs(sql, *args, i == 0); err != nil {
+			return "", nil, err
+		}
+	}
+	return w.String(), w.args, nil
+}
+
+// bindNamedArgs binds the named parameters in args for the placeholders of sql, and
+// writes the sql with the placeholders of the dialect if write is true. The args
+// are compacted in place, as a position never exceeds the index.
+func (w *Writer) bindNamedArgs(sql string, args []any, write bool) ([]any, error) {
+	bound := args[:0]
+	// positions is keyed by the *namedArg or the name of the slot
+	var positions map[any]int
+	start, i := 0, 0
+	for {
+		index := strings.Index(sql[start:], db.Para)
+		if index < 0 {
+			if write {
+				w.WriteString(sql[start:])
+			}
+			break
+		}
+		if write {
+			w.WriteString(sql[start : start+index])
+		}
+		start += index + len(db.Para)
+
+		pos := 0
+		if i >= len(args) {
+			// more placeholders than args, which is reported by the database
+			pos = len(bound) + 1
+		} else {
+			arg := args[i]
+			i++
+			var key any
+			switch named := arg.(type) {
+			case *namedArg:
+				if !named.bound {
+					return nil, &ParamError{Name: named.name}
+				}
+				key, arg = named, named.value
+			case SlotArg:
+				// the unnamed slots are bound by position only
+				if named.Name != "" {
+					key = named.Name
+				}
+			}
+
+			if p, seen := positions[key]; seen {
+				pos = p
+			}
+			if pos == 0 {
+				bound = append(bound, arg)
+				pos = len(bound)
+				if key != nil && w.schema == db.SchPG {
+					if positions == nil {
+						positions = make(map[any]int)
+					}
+					positions[key] = pos
+				}
+			}
+		}
+
+		if !write {
+			continue
+		}
+		if w.schema == db.SchPG {
+			w.WriteByte('$')
+			w.WriteString(strconv.Itoa(pos))
+		} else {
+			w.WriteByte('?')
+		}
+	}
+	return bound, nil
+}
@@ -0,0 +1,163 @@
+package sqlBuilderV3
+
+import (
+	"context"
+	"errors"
+	"reflect"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/secure-for-ai/secureai-microsvs/db"
+	"github.com/secure-for-ai/secureai-microsvs/db/pgdb"
+)
+
+// queryRows generates the sql of stmt in the Postgres dialect and runs the query.
+// stmt is a select, a compound select, or a statement with RETURNING.
+func queryRows(ctx context.Context, q pgdb.PGQuerier, stmt *Stmt) (pgx.Rows, error) {
+	w := NewWriter()
+	defer w.Destroy()
+	sql, args, err := stmt.Gen(w, db.SchPG)
+	if err != nil {
+		return nil, err
+	}
+
+	analyzeQuery(q, ctx, sql, args...)
+	name, err := prepareSQL(q, ctx, sql)
+	if err != nil {
+		return nil, err
+	}
+	return queryStmt(q, ctx, name, args...)
+}
+
+// isStruct checks whether T is scanned by the db tags of its fields, otherwise
+// T is scanned from the first column directly, e.g., time.Time or pgtype.Int8.
+func isStruct[T any]() bool {
+	t := reflect.TypeFor[T]()
+	return t.Kind() == reflect.Struct && t != timeType && !reflect.PointerTo(t).Implements(scannerType)
+}
+
+// maxResultCap is the maximum capacity pre-allocated for the rows by the limit
+const maxResultCap = 1024
+
+// resultCap returns the capacity to pre-allocate for the rows up to the limit
+func resultCap(limit int) int {
+	return min(max(limit, 0), maxResultCap)
+}
+
+// structScanOne scans the first row into dest like pgdb.StructScanOne, but returns
+// the error of the failed query, which has no row either, instead of pgdb.ErrFindNil.
+func structScanOne(rows pgx.Rows, dest any) error {
+	err := pgdb.StructScanOne(rows, dest)
+	rows.Close()
+	if rowsErr := rows.Err(); rowsErr != nil && (err == nil || errors.Is(err, pgdb.ErrFindNil)) {
+		return rowsErr
+	}
+	return err
+}
+
+// scanColumn scans the first column of every row into a T until fn returns false.
+// rows is always closed afterwards.
+func scanColumn[T any](rows pgx.Rows, fn func(v T) bool) error {
+	defer rows.Close()
+
+	for rows.Next() {
+		// a new value for every row, as the scanned slice or map is not copied
+		var v T
+		if err := rows.Scan(&v); err != nil {
+			return err
+		}
+		if !fn(v) {
+			break
+		}
+	}
+	rows.Close()
+	return rows.Err()
+}
+
+// QueryAll runs the query and returns the rows as a slice of T, which is a struct
+// mapped by the db tags, or a type scanned from the single column, e.g.,
+//
+//	users, err := QueryAll[User](ctx, conn, Select(User{}).Where(Map{"gid": 1}))
+//	uids, err := QueryAll[int64](ctx, conn, Select("uid").From("user"))
+//
+// The slice is pre-allocated up to the limit of stmt, at most 1024 rows.
+func QueryAll[T any](ctx context.Context, q pgdb.PGQuerier, stmt *Stmt) ([]T, error) {
+	rows, err := queryRows(ctx, q, stmt)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]T, 0, resultCap(stmt.LimitN))
+	if isStruct[T]() {
+		err = pgdb.StructScanSlice(rows, &result)
+		if err == nil {
+			err = rows.Err()
+		}
+		return result, err
+	}
+
+	err = scanColumn(rows, func(v T) bool {
+		result = append(result, v)
+		return true
+	})
+	return result, err
+}
+
+// QueryOne runs the query and returns the first row as T. It returns
+// pgdb.ErrFindNil if there is no row.
+func QueryOne[T any](ctx context.Context, q pgdb.PGQuerier, stmt *Stmt) (T, error) {
+	var result T
+	rows, err := queryRows(ctx, q, stmt)
+	if err != nil {
+		return result, err
+	}
+
+	if isStruct[T]() {
+		return result, structScanOne(rows, &result)
+	}
+	return scalarRows[T](rows)
+}
+
+// QueryScalar runs the query returning a single value, e.g., COUNT, SUM or MAX,
+// and returns the first column of the first row. It returns pgdb.ErrFindNil if
+// there is no row. Use a pointer or a pgtype type as T if the value can be NULL,
+// e.g., SUM of no row.
+func QueryScalar[T any](ctx context.Context, q pgdb.PGQuerier, stmt *Stmt) (T, error) {
+	rows, err := queryRows(ctx, q, stmt)
+	if err != nil {
+		var zero T
+		return zero, err
+	}
+	return scalarRows[T](rows)
+}
+
+// scalarRows scans the first column of the first row of rows into T, and closes
+// rows. It returns pgdb.ErrFindNil if there is no row.
+func scalarRows[T any](rows pgx.Rows) (T, error) {
+	var result T
+	found := false
+	err := scanColumn(rows, func(v T) bool {
+		result, found = v, true
+		return false
+	})
+	if err == nil && !found {
+		err = pgdb.ErrFindNil
+	}
+	return result, err
+}
+
+// QueryMap runs the query and returns the rows as maps from the column names
+// to the values. The slice is pre-allocated up to the limit of stmt, at most 1024
+// rows.
+func QueryMap(ctx context.Context, q pgdb.PGQuerier, stmt *Stmt) ([]map[string]any, error) {
+	rows, err := queryRows(ctx, q, stmt)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]map[string]any, 0, resultCap(stmt.LimitN))
+	err = pgdb.PGMapScan(rows, &result)
+	if err == nil {
+		err = rows.Err()
+	}
+	return result, err
+}
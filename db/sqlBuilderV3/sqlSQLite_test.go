@@ -0,0 +1,153 @@
+//go:build cgo
+
+package sqlBuilderV3_test
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/secure-for-ai/secureai-microsvs/db/sqlBuilderV3"
+	"github.com/stretchr/testify/assert"
+)
+
+type liteUser struct {
+	Uid     int64          `db:"uid,pk"`
+	Name    string         `db:"name"`
+	Avatar  []byte         `db:"avatar"`
+	Tags    []string       `db:"tags"`
+	Profile map[string]any `db:"profile"`
+	Visits  int64          `db:"visits,omitempty"`
+}
+
+func (liteUser) GetTableName() string {
+	return "users"
+}
+
+// openSQLite opens an in-memory database, which lives as long as its only connection
+func openSQLite(t *testing.T) *sql.DB {
+	conn, err := sql.Open("sqlite3", ":memory:")
+	assert.NoError(t, err)
+	conn.SetMaxOpenConns(1)
+
+	_, err = conn.Exec(`CREATE TABLE users (
+	uid INTEGER PRIMARY KEY,
+	name TEXT NOT NULL,
+	avatar BLOB,
+	tags TEXT,
+	profile TEXT,
+	visits INTEGER NOT NULL DEFAULT 0
+)`)
+	assert.NoError(t, err)
+	return conn
+}
+
+func TestSQLite(t *testing.T) {
+	conn := openSQLite(t)
+	defer conn.Close()
+	ctx := context.Background()
+
+	alice := liteUser{
+		Uid:     1 << 40,
+		Name:    "alice",
+		Avatar:  []byte{0, 1, 0xff},
+		Tags:    []string{"admin", "dev"},
+		Profile: map[string]any{"age": float64(30), "lang": "go"},
+	}
+	bob := liteUser{Uid: 2, Name: "bob", Tags: []string{}}
+
+	// RETURNING a struct and a slice of struct
+	var ret liteUser
+	n, err := sqlBuilderV3.Insert(&alice).Returning("uid", "name").ExecSQLite(conn, ctx, &ret)
+	assert.NoError(t, err)
+	assert.EqualValues(t, 1, n)
+	assert.EqualValues(t, alice.Uid, ret.Uid)
+	assert.EqualValues(t, "alice", ret.Name)
+
+	var rets []liteUser
+	_, err = sqlBuilderV3.InsertBulk([]liteUser{bob, {Uid: 3, Name: "carol"}}).
+		Returning("uid").ExecSQLite(conn, ctx, &rets)
+	assert.NoError(t, err)
+	assert.Len(t, rets, 2)
+	assert.EqualValues(t, 2, rets[0].Uid)
+	assert.EqualValues(t, 3, rets[1].Uid)
+
+	// the JSON columns and the blob are read back, and the int64 is kept exactly
+	var got liteUser
+	_, err = sqlBuilderV3.Select(&liteUser{}).Where(sqlBuilderV3.Map{"uid": alice.Uid}).ExecSQLite(conn, ctx, &got)
+	assert.NoError(t, err)
+	assert.EqualValues(t, alice, got)
+
+	var raw []map[string]any
+	_, err = sqlBuilderV3.Select().SelectColumns("uid", "avatar", "tags").From("users").
+		Where(sqlBuilderV3.Map{"uid": alice.Uid}).ExecSQLite(conn, ctx, &raw)
+	assert.NoError(t, err)
+	assert.Len(t, raw, 1)
+	assert.EqualValues(t, int64(1<<40), raw[0]["uid"])
+	assert.EqualValues(t, []byte{0, 1, 0xff}, raw[0]["avatar"])
+	assert.EqualValues(t, `["admin","dev"]`, raw[0]["tags"])
+
+	// the upsert updates the conflicting row
+	alice.Name = "Alice"
+	n, err = sqlBuilderV3.Insert(&alice).OnConflict("uid").DoUpdateExcluded("name").
+		DoUpdateSet(sqlBuilderV3.Expr("visits = users.visits + :inc", sqlBuilderV3.Map{"inc": 2})).
+		Returning("name", "visits").ExecSQLite(conn, ctx, &ret)
+	assert.NoError(t, err)
+	assert.EqualValues(t, 1, n)
+	assert.EqualValues(t, "Alice", ret.Name)
+	assert.EqualValues(t, 2, ret.Visits)
+
+	n, err = sqlBuilderV3.Insert(&bob).OnConflict("uid").DoNothing().ExecSQLite(conn, ctx)
+	assert.NoError(t, err)
+	assert.EqualValues(t, 0, n)
+
+	var users []liteUser
+	_, err = sqlBuilderV3.Select(&liteUser{}).Asc("uid").ExecSQLite(conn, ctx, &users)
+	assert.NoError(t, err)
+	assert.Len(t, users, 3)
+	assert.EqualValues(t, []string{}, users[0].Tags)
+	assert.Nil(t, users[0].Profile)
+	assert.Nil(t, users[1].Tags)
+	alice.Visits = 2
+	assert.EqualValues(t, alice, users[2])
+
+	// ILIKE is rewritten with LOWER
+	var found []liteUser
+	_, err = sqlBuilderV3.Select(&liteUser{}).Where(sqlBuilderV3.ILike("name", "ALI%")).ExecSQLite(conn, ctx, &found)
+	assert.NoError(t, err)
+	assert.EqualValues(t, []liteUser{alice}, found)
+
+	// the compound members having their own ORDER BY and LIMIT
+	var ends []liteUser
+	stmt := sqlBuilderV3.Union(
+		sqlBuilderV3.Select().SelectColumns("uid").From("users").Asc("uid").Limit(1),
+		sqlBuilderV3.Select().SelectColumns("uid").From("users").Desc("uid").Limit(1),
+	).Asc("uid")
+	_, err = stmt.ExecSQLite(conn, ctx, &ends)
+	stmt.Destroy()
+	assert.NoError(t, err)
+	assert.EqualValues(t, []liteUser{{Uid: 2}, {Uid: alice.Uid}}, ends)
+
+	// RETURNING of delete
+	n, err = sqlBuilderV3.Delete().From("users").Where("uid < ??", 10).Returning("uid").ExecSQLite(conn, ctx, &rets)
+	assert.NoError(t, err)
+	assert.EqualValues(t, 2, n)
+
+	// RETURNING emulated for MySQL, whose quoted identifiers SQLite accepts as well.
+	// The deletion of no row returns nothing instead of pgdb.ErrFindNil.
+	var deleted liteUser
+	n, err = sqlBuilderV3.Delete("users", sqlBuilderV3.Map{"uid": 404}).Returning("uid", "name").
+		ExecMySQL(conn, ctx, &deleted)
+	assert.NoError(t, err)
+	assert.EqualValues(t, 0, n)
+	assert.Zero(t, deleted)
+	n, err = sqlBuilderV3.Delete("users", sqlBuilderV3.Map{"uid": alice.Uid}).Returning("uid", "name").
+		ExecMySQL(conn, ctx, &deleted)
+	assert.NoError(t, err)
+	assert.EqualValues(t, 1, n)
+	assert.EqualValues(t, liteUser{Uid: alice.Uid, Name: alice.Name}, deleted)
+	n, err = sqlBuilderV3.Select("users").ExecSQLite(conn, ctx)
+	assert.NoError(t, err)
+	assert.EqualValues(t, 0, n)
+}
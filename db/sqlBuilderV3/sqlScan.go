@@ -0,0 +1,274 @@
+package sqlBuilderV3
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/secure-for-ai/secureai-microsvs/db"
+	"github.com/secure-for-ai/secureai-microsvs/db/pgdb"
+	"github.com/secure-for-ai/secureai-microsvs/util"
+)
+
+// scanSQL scans rows into res, which can be a struct, a slice of struct, *[]map[string]any
+// or *[][]any. rows is always closed afterwards. It returns the number of scanned rows.
+func scanSQL(rows *sql.Rows, limit int, res any) (int64, error) {
+	defer rows.Close()
+
+	var n int64
+	var err error
+	resValue := util.ReflectValue(res)
+
+	switch resValue.Kind() {
+	case reflect.Struct:
+		n, err = sqlStructScanOne(rows, resValue)
+	case reflect.Slice:
+		// if the data type of res is a slice, then pre-allocate
+		// the memory up to limit slots in case of resValue.Cap() < limit
+		if limit = resultCap(limit); resValue.Cap() < limit {
+			resValue.Set(reflect.MakeSlice(resValue.Type(), 0, limit))
+		}
+
+		switch res := res.(type) {
+		case *[]map[string]any:
+			n, err = sqlMapScan(rows, res)
+		case *[][]any:
+			n, err = sqlArrayScan(rows, res)
+		default:
+			n, err = sqlStructScanSlice(rows, resValue)
+		}
+	default:
+		return 0, errors.New("not support result data type: " + reflect.TypeOf(res).String())
+	}
+
+	if err != nil {
+		return n, err
+	}
+	return n, rows.Err()
+}
+
+// sqlScanArgs points args to the fields of v by the field map of db.FieldMap, which
+// is shared with the pgdb scanners. The column without a field is scanned into a
+// placeholder and dropped, and the JSON column is decoded into the field of slice,
+// map or struct.
+func sqlScanArgs(v reflect.Value, fieldMap []int, args []any) {
+	for i, index := range fieldMap {
+		if index < 0 {
+			args[i] = new(any)
+			continue
+		}
+
+		field := v.Field(index)
+		if t := field.Type(); isJSONType(t) && !t.Implements(scannerType) &&
+			!reflect.PointerTo(t).Implements(scannerType) {
+			args[i] = jsonField{field}
+		} else {
+			args[i] = field.Addr().Interface()
+		}
+	}
+}
+
+var (
+	timeType    = reflect.TypeOf(time.Time{})
+	valuerType  = reflect.TypeOf((*driver.Valuer)(nil)).Elem()
+	scannerType = reflect.TypeOf((*sql.Scanner)(nil)).Elem()
+)
+
+// isJSONType checks whether the data of type t is stored as JSON in the dialect
+// without array types, i.e., slices except []byte, arrays, maps and structs
+// except time.Time. The type implementing driver.Valuer is kept as it is.
+func isJSONType(t reflect.Type) bool {
+	if t == nil || t.Implements(valuerType) {
+		return false
+	}
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	switch t.Kind() {
+	case reflect.Slice:
+		return t.Elem().Kind() != reflect.Uint8
+	case reflect.Array, reflect.Map:
+		return true
+	case reflect.Struct:
+		return t != timeType
+	}
+	return false
+}
+
+// jsonArgs encodes the args of JSON types into JSON text
+func jsonArgs(args []any) error {
+	for i, arg := range args {
+		if !isJSONType(reflect.TypeOf(arg)) {
+			continue
+		}
+
+		b, err := json.Marshal(arg)
+		if err != nil {
+			return err
+		}
+		args[i] = string(b)
+	}
+	return nil
+}
+
+// jsonField decodes the JSON column into the field
+type jsonField struct {
+	field reflect.Value
+}
+
+func (f jsonField) Scan(src any) error {
+	// reset the field first, as the decoder reuses the slice and the map,
+	// which are shared with the previous row.
+	f.field.SetZero()
+
+	switch src := src.(type) {
+	case nil:
+		return nil
+	case []byte:
+		return json.Unmarshal(src, f.field.Addr().Interface())
+	case string:
+		return json.Unmarshal([]byte(src), f.field.Addr().Interface())
+	}
+	return errors.New("cannot decode JSON from " + reflect.TypeOf(src).String())
+}
+
+func sqlStructScanOne(rows *sql.Rows, v reflect.Value) (int64, error) {
+	cols, err := rows.Columns()
+	if err != nil {
+		return 0, err
+	}
+
+	if !rows.Next() {
+		if err = rows.Err(); err != nil {
+			return 0, err
+		}
+		return 0, pgdb.ErrFindNil
+	}
+
+	args := make([]any, len(cols))
+	sqlScanArgs(v, db.FieldMap(v.Type(), cols), args)
+	if err = rows.Scan(args...); err != nil {
+		return 0, err
+	}
+	return 1, nil
+}
+
+func sqlStructScanSlice(rows *sql.Rows, slice reflect.Value) (int64, error) {
+	base := slice.Type().Elem()
+	if base.Kind() != reflect.Struct {
+		return 0, errors.New("not support result data type: []" + base.String())
+	}
+
+	cols, err := rows.Columns()
+	if err != nil {
+		return 0, err
+	}
+
+	// scan every row into v, and then append a copy of v to the slice
+	v := reflect.New(base).Elem()
+	args := make([]any, len(cols))
+	sqlScanArgs(v, db.FieldMap(base, cols), args)
+
+	var n int64 = 0
+	tmpSlice := slice
+	for rows.Next() {
+		if err = rows.Scan(args...); err != nil {
+			return n, err
+		}
+		tmpSlice = reflect.Append(tmpSlice, v)
+		n++
+	}
+
+	slice.Set(tmpSlice)
+	return n, nil
+}
+
+func sqlMapScan(rows *sql.Rows, maps *[]map[string]any) (int64, error) {
+	cols, err := rows.Columns()
+	if err != nil {
+		return 0, err
+	}
+	text, err := sqlTextColumns(rows)
+	if err != nil {
+		return 0, err
+	}
+
+	var n int64 = 0
+	for rows.Next() {
+		v, err := sqlValues(rows, text)
+		if err != nil {
+			return n, err
+		}
+
+		// specific the hint size of the map in order to avoid extra memory allocation
+		m := make(map[string]any, len(cols))
+		for i := range cols {
+			m[cols[i]] = v[i]
+		}
+		*maps = append(*maps, m)
+		n++
+	}
+
+	return n, nil
+}
+
+func sqlArrayScan(rows *sql.Rows, arrays *[][]any) (int64, error) {
+	text, err := sqlTextColumns(rows)
+	if err != nil {
+		return 0, err
+	}
+
+	var n int64 = 0
+	for rows.Next() {
+		v, err := sqlValues(rows, text)
+		if err != nil {
+			return n, err
+		}
+		*arrays = append(*arrays, v)
+		n++
+	}
+
+	return n, nil
+}
+
+// sqlTextColumns finds the columns whose []byte values are text. Drivers like
+// MySQL return text as []byte, which is converted to string for maps and arrays.
+func sqlTextColumns(rows *sql.Rows) ([]bool, error) {
+	types, err := rows.ColumnTypes()
+	if err != nil {
+		return nil, err
+	}
+
+	text := make([]bool, len(types))
+	for i, t := range types {
+		name := strings.ToUpper(t.DatabaseTypeName())
+		text[i] = !strings.Contains(name, "BLOB") && !strings.Contains(name, "BINARY") &&
+			name != "BYTEA"
+	}
+	return text, nil
+}
+
+// sqlValues returns the values of the current row
+func sqlValues(rows *sql.Rows, text []bool) ([]any, error) {
+	v := make([]any, len(text))
+	args := make([]any, len(text))
+	for i := range v {
+		args[i] = &v[i]
+	}
+
+	if err := rows.Scan(args...); err != nil {
+		return nil, err
+	}
+
+	for i := range v {
+		if b, ok := v[i].([]byte); ok && text[i] {
+			v[i] = string(b)
+		}
+	}
+	return v, nil
+}
@@ -0,0 +1,191 @@
+package sqlBuilderV3
+
+import (
+	"strings"
+
+	"github.com/secure-for-ai/secureai-microsvs/db"
+)
+
+type assignOp int
+
+const (
+	// setValue is "col = sql"
+	setValue assignOp = iota
+	// setInc is "col = col + ??"
+	setInc
+	// setDec is "col = col - ??"
+	setDec
+	// setExcluded is "col = EXCLUDED.col"
+	setExcluded
+	// setRaw is the whole assignment given by an expression, e.g., ExprEq
+	setRaw
+)
+
+// setItem is an assignment of SET. The column is kept apart from the value, so
+// that it is quoted for the dialect when the statement is written.
+type setItem struct {
+	col string
+	op  assignOp
+	sql string
+}
+
+// setList is the assignments of "UPDATE ... SET" and "ON CONFLICT DO UPDATE SET".
+// The args of the items are kept in order.
+type setList struct {
+	items []setItem
+	args  []any
+	// text is the assignments written as given, i.e., Stmt.SetCols, if not nil
+	text *condExpr
+}
+
+func (setCols *setList) IsValid() bool {
+	return len(setCols.items) > 0
+}
+
+func (setCols *setList) Reset() {
+	clear(setCols.args)
+	setCols.items = setCols.items[:0]
+	setCols.args = setCols.args[:0]
+	if setCols.text != nil {
+		setCols.text.Reset()
+	}
+}
+
+func (setCols *setList) WriteTo(w *Writer) {
+	setCols.writeTo(w, false)
+}
+
+// writeTo writes the assignments separated by ",". The values of the row proposed
+// for insertion, i.e., "EXCLUDED.col", are rewritten to "VALUES(col)" if values is
+// true, which is the upsert of MySQL.
+func (setCols *setList) writeTo(w *Writer, values bool) {
+	for i, item := range setCols.items {
+		if i > 0 {
+			w.WriteByte(',')
+		}
+		if item.op == setRaw {
+			excludedWriteTo(w, item.sql, values)
+			continue
+		}
+
+		w.WriteIdent(item.col)
+		w.WriteString(" = ")
+		switch item.op {
+		case setInc, setDec:
+			w.WriteIdent(item.col)
+			if item.op == setInc {
+				w.WriteString(" + ")
+			} else {
+				w.WriteString(" - ")
+			}
+			w.WriteString(db.Para)
+		case setExcluded:
+			excludedColWriteTo(w, item.col, values)
+		default:
+			excludedWriteTo(w, item.sql, values)
+		}
+	}
+	w.Append(setCols.args...)
+}
+
+// excludedColWriteTo writes "EXCLUDED.col", or "VALUES(col)" if values is true
+func excludedColWriteTo(w *Writer, col string, values bool) {
+	if values {
+		w.WriteString("VALUES(")
+		w.WriteIdent(col)
+		w.WriteByte(')')
+		return
+	}
+	w.WriteString(excludedPrefix)
+	w.WriteIdent(col)
+}
+
+// excludedWriteTo writes the expression, in which "EXCLUDED.col" is rewritten to
+// "VALUES(col)" if values is true.
+func excludedWriteTo(w *Writer, sql string, values bool) {
+	if !values {
+		w.WriteString(sql)
+		return
+	}
+
+	for {
+		i := strings.Index(sql, excludedPrefix)
+		if i < 0 {
+			w.WriteString(sql)
+			return
+		}
+		w.WriteString(sql[:i])
+		sql = sql[i+len(excludedPrefix):]
+
+		j := 0
+		for j < len(sql) && isIdentByte(sql[j]) {
+			j++
+		}
+		excludedColWriteTo(w, sql[:j], true)
+		sql = sql[j:]
+	}
+}
+
+func (setCols *setList) add(col string, op assignOp, sql string, args ...any) {
+	setCols.items = append(setCols.items, setItem{col: col, op: op, sql: sql})
+	setCols.args = append(setCols.args, args...)
+
+	text := setCols.text
+	if text == nil {
+		return
+	}
+	if text.IsValid() {
+		text.appendSql(",")
+	}
+	switch op {
+	case setRaw:
+		text.appendSql(sql)
+	case setInc:
+		text.appendSql(col + " = " + col + " + " + db.Para)
+	case setDec:
+		text.appendSql(col + " = " + col + " - " + db.Para)
+	case setExcluded:
+		text.appendSql(col + " = " + excludedPrefix + col)
+	default:
+		text.appendSql(col + " = " + sql)
+	}
+	text.appendArgs(args...)
+}
+
+// appendExpr appends the assignment given by the expression, e.g., "col = NOW()"
+func (setCols *setList) appendExpr(e *condExpr) {
+	setCols.add("", setRaw, e.String(), e.args...)
+}
+
+// appendEq appends "col = ??"
+func (setCols *setList) appendEq(col string, arg any) {
+	setCols.add(col, setValue, db.Para, arg)
+}
+
+// appendInc appends "col = col + ??", and the arg is 1 by default
+func (setCols *setList) appendInc(col string, args ...any) {
+	var para any = 1
+	if len(args) > 0 {
+		para = args[0]
+	}
+	setCols.add(col, setInc, "", para)
+}
+
+// appendDec appends "col = col - ??", and the arg is 1 by default
+func (setCols *setList) appendDec(col string, args ...any) {
+	var para any = 1
+	if len(args) > 0 {
+		para = args[0]
+	}
+	setCols.add(col, setDec, "", para)
+}
+
+// appendSet appends "col = val"
+func (setCols *setList) appendSet(col string, val string, args ...any) {
+	setCols.add(col, setValue, val, args...)
+}
+
+// appendExcluded appends "col = EXCLUDED.col"
+func (setCols *setList) appendExcluded(col string) {
+	setCols.add(col, setExcluded, "")
+}
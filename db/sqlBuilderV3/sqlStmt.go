@@ -19,6 +19,7 @@ const (
 	UpdateType
 	SelectType
 	UpsertType
+	CompoundType
 )
 
 type Columns []string
@@ -57,7 +58,7 @@ func (from *fromTable) setAliasName(name string) {
 }
 
 func (from *fromTable) writeTo(w *Writer) {
-	w.WriteString(from.tableName)
+	w.WriteIdent(from.tableName)
 	if len(from.alias) > 0 {
 		w.WriteString(" AS ")
 		w.WriteString(from.alias)
@@ -92,7 +93,9 @@ func (from *fromStmt) setAliasName(name string) {
 
 func (from *fromStmt) writeTo(w *Writer) {
 	w.WriteByte('(')
-	from.stmt.WriteTo(w)
+	if err := from.stmt.WriteTo(w); err != nil {
+		w.setErr(err)
+	}
 
 	if len(from.alias) > 0 {
 		w.WriteString(") AS ")
@@ -107,33 +110,150 @@ func (from *fromStmt) destroy() {
 	fromStmtPool.Put(from)
 }
 
+// createFromItem wraps a table name, a Table or a sub statement into a fromItem.
+// It returns nil if the subject is not supported.
+func createFromItem(subject any) fromItem {
+	switch subject := subject.(type) {
+	case *Stmt:
+		//subquery should be a select statement, and we only accept one select stmt
+		return createFromStmt(subject, "")
+	case Table:
+		return createFromTable(subject.GetTableName(), "")
+	case string:
+		return createFromTable(subject, "")
+	}
+	return nil
+}
+
+type joinType int
+
+const (
+	joinDefault joinType = iota
+	joinInner
+	joinLeft
+	joinRight
+	joinFull
+	joinCross
+)
+
+var joinTypeStr = [...]string{
+	joinDefault: " JOIN ",
+	joinInner:   " INNER JOIN ",
+	joinLeft:    " LEFT JOIN ",
+	joinRight:   " RIGHT JOIN ",
+	joinFull:    " FULL JOIN ",
+	joinCross:   " CROSS JOIN ",
+}
+
+var fromJoinPool = sync.Pool{
+	New: func() any {
+		return &fromJoin{
+			on:    condEmpty{},
+			onRef: make([]Cond, 0, 2),
+		}
+	},
+}
+
+type fromJoin struct {
+	joinType joinType
+	item     fromItem
+	on       Cond
+	// tracker internal created conds. destroy() only destroy
+	// refed conds. This can avoid double free.
+	onRef []Cond
+}
+
+func createFromJoin(joinType joinType, item fromItem) *fromJoin {
+	from := fromJoinPool.Get().(*fromJoin)
+	from.joinType = joinType
+	from.item = item
+	return from
+}
+
+func (from *fromJoin) setAliasName(name string) {
+	from.item.setAliasName(name)
+}
+
+func (from *fromJoin) writeTo(w *Writer) {
+	w.WriteString(joinTypeStr[from.joinType])
+	from.item.writeTo(w)
+
+	if from.on.IsValid() {
+		w.WriteString(" ON ")
+		from.on.WriteTo(w)
+	}
+}
+
+func (from *fromJoin) destroy() {
+	from.item.destroy()
+	from.item = nil
+	from.on = condEmpty{}
+	for _, cond := range from.onRef {
+		cond.Destroy()
+	}
+	from.onRef = from.onRef[:0]
+	fromJoinPool.Put(from)
+}
+
 type Stmt struct {
 	RefTable *Table
 
+	with []*withItem
+
 	tableInto string
 	tableFrom []fromItem
+	tableJoin []*fromJoin
 
 	where Cond
 	// tracker internal created conds. Reset() only destroy
 	// refed conds. This can avoid double free.
 	whereRef []Cond
 
+	// GroupByStr is the keys of GROUP BY as given, and groupBy is the same keys,
+	// which are quoted for the dialect when the statement is written.
 	GroupByStr *stringWriter
+	groupBy    []string
 	having     Cond
 	// tracker internal created conds. Reset() only destroy
 	// refed conds. This can avoid double free.
 	havingRef  []Cond
 	OrderByStr *stringWriter
+	// orders is the parsed items of OrderByStr
+	orders []orderItem
+	// cursor of the keyset pagination
+	cursor       *Cursor
+	cursorBefore bool
 
 	Offset int
 	LimitN int
 
+	lock rowLock
+
 	InsertCols   []string
 	InsertValues valExpr2DList
+	multiRow     bool
 
+	// SetCols is the assignments of SET as given, and assigns is the same ones,
+	// whose columns are quoted for the dialect when the statement is written.
 	SetCols *condExpr
+	assigns *setList
 
 	SelectCols []string
+	distinct   bool
+	distinctOn []string
+	// selectExprs and windows are written along with SelectCols
+	selectExprs []*selectExpr
+	windows     []namedWindow
+
+	ReturningCols []string
+
+	conflict onConflict
+
+	compound []compoundItem
+
+	strict strictMode
+	// err is the error found when building the statement, which is returned by Gen
+	err error
 
 	sqlType Type
 }
@@ -178,23 +298,42 @@ func SQL() *Stmt {
 func (stmt *Stmt) Init() {
 	stmt.RefTable = nil
 
+	stmt.with = make([]*withItem, 0, 1)
+
 	stmt.tableInto = ""
 	stmt.tableFrom = make([]fromItem, 0, 2)
+	stmt.tableJoin = make([]*fromJoin, 0, 2)
 
 	stmt.where = condEmpty{}
 	stmt.whereRef = make([]Cond, 0, 2)
 	stmt.GroupByStr = new(stringWriter)
+	stmt.groupBy = []string{}
 	stmt.having = condEmpty{}
 	stmt.havingRef = make([]Cond, 0, 2)
 	stmt.OrderByStr = new(stringWriter)
+	stmt.orders = make([]orderItem, 0, 2)
+	stmt.cursor = nil
+	stmt.cursorBefore = false
 
 	stmt.Offset = 0
 	stmt.LimitN = 0
+	stmt.lock.init()
 
 	stmt.InsertCols = []string{}
 	stmt.InsertValues = newValExpr2DList(2)
+	stmt.multiRow = false
 	stmt.SetCols = Expr("")
+	stmt.assigns = &setList{text: stmt.SetCols}
 	stmt.SelectCols = []string{}
+	stmt.distinct = false
+	stmt.distinctOn = []string{}
+	stmt.selectExprs = make([]*selectExpr, 0)
+	stmt.windows = make([]namedWindow, 0)
+	stmt.ReturningCols = []string{}
+	stmt.conflict.init()
+	stmt.compound = make([]compoundItem, 0)
+	stmt.strict.reset()
+	stmt.err = nil
 
 	stmt.sqlType = RawType
 }
@@ -202,11 +341,20 @@ func (stmt *Stmt) Init() {
 func (stmt *Stmt) Reset() {
 	stmt.RefTable = nil
 
+	for _, item := range stmt.with {
+		item.destroy()
+	}
+	stmt.with = stmt.with[:0]
+
 	stmt.tableInto = ""
 	for _, from := range stmt.tableFrom {
 		from.destroy()
 	}
 	stmt.tableFrom = stmt.tableFrom[:0]
+	for _, join := range stmt.tableJoin {
+		join.destroy()
+	}
+	stmt.tableJoin = stmt.tableJoin[:0]
 
 	stmt.where = condEmpty{}
 	for _, cond := range stmt.whereRef {
@@ -214,20 +362,44 @@ func (stmt *Stmt) Reset() {
 	}
 	stmt.whereRef = stmt.whereRef[:0]
 	stmt.GroupByStr.Reset()
+	stmt.groupBy = stmt.groupBy[:0]
 	stmt.having = condEmpty{}
 	for _, cond := range stmt.havingRef {
 		cond.Destroy()
 	}
 	stmt.havingRef = stmt.havingRef[:0]
 	stmt.OrderByStr.Reset()
+	stmt.orders = stmt.orders[:0]
+	stmt.cursor = nil
+	stmt.cursorBefore = false
 
 	stmt.Offset = 0
 	stmt.LimitN = 0
+	stmt.lock.reset()
 
 	stmt.InsertCols = stmt.InsertCols[:0]
 	stmt.InsertValues.reset()
-	stmt.SetCols.Reset()
+	stmt.multiRow = false
+	stmt.assigns.Reset()
 	stmt.SelectCols = stmt.SelectCols[:0]
+	stmt.distinct = false
+	stmt.distinctOn = stmt.distinctOn[:0]
+	for _, item := range stmt.selectExprs {
+		item.destroy()
+	}
+	stmt.selectExprs = stmt.selectExprs[:0]
+	for _, win := range stmt.windows {
+		win.def.Destroy()
+	}
+	stmt.windows = stmt.windows[:0]
+	stmt.ReturningCols = stmt.ReturningCols[:0]
+	stmt.conflict.reset()
+	for _, item := range stmt.compound {
+		item.stmt.Destroy()
+	}
+	stmt.compound = stmt.compound[:0]
+	stmt.strict.reset()
+	stmt.err = nil
 
 	stmt.sqlType = RawType
 }
@@ -238,6 +410,7 @@ func (stmt *Stmt) Destroy() {
 }
 
 // Todo replace with the fast version of sync map
+// structColumnCache caches *structColumns by the struct type
 var structColumnCache = sync.Map{}
 
 type stringWriter struct {
@@ -271,48 +444,25 @@ var bufPool = sync.Pool{
 }
 
 func buildColumnsInternal(v reflect.Value, vType reflect.Type) []string {
-	// construct the unique name of the struct with zero-copy method
-	w := bufPool.Get().(*stringWriter)
-	w.WriteString(vType.PkgPath())
-	w.WriteByte('.')
-	w.WriteString(vType.Name())
-	structFullName := w.String()
-	structColNames, ok := structColumnCache.Load(structFullName)
+	return getStructColumns(vType).names
+}
 
-	// write the column name of the struct from the cache
-	if ok {
-		// free *stringWriter
-		w.Destroy()
-		return structColNames.([]string)
-	}
+func buildColumns(colNames *[]string, column any) {
+	v := util.ReflectValue(column)
+	vType := v.Type()
 
-	// avoid extend the slice cap which causes memory reallocation
-	numField := v.NumField()
-	tmpColNames := make([]string, numField)
-	for i, il := 0, numField; i < il; i++ {
-		// Get column name, tag start with "pg" or the field Name
-		var colName string
-		fieldInfo := vType.Field(i)
-		if colName = fieldInfo.Tag.Get(db.Tag); colName == "" {
-			colName = fieldInfo.Name
-		}
-		tmpColNames[i] = colName
+	if vType.Kind() == reflect.Struct {
+		*colNames = append(*colNames, buildColumnsInternal(v, vType)...)
 	}
-
-	// store the column name of the struct into the cache
-	structColumnCache.Store(strings.Clone(structFullName), tmpColNames)
-	// free *stringWriter
-	w.Destroy()
-
-	return tmpColNames
-
 }
-func buildColumns(colNames *[]string, column any) {
+
+// buildInsertColumns builds the columns to insert, i.e., the ones not read-only
+func buildInsertColumns(colNames *[]string, column any) {
 	v := util.ReflectValue(column)
 	vType := v.Type()
 
 	if vType.Kind() == reflect.Struct {
-		*colNames = append(*colNames, buildColumnsInternal(v, vType)...)
+		*colNames = append(*colNames, getStructColumns(vType).writableNames...)
 	}
 }
 
@@ -323,11 +473,12 @@ func buildValues(curData any) *valExprList {
 	v := util.ReflectValue(curData)
 	vType := v.Type()
 	if vType.Kind() == reflect.Struct {
-		numField := v.NumField()
-		values := getValExprListWithSize(numField) //make([]condExpr, numField)
-		for i, il := 0, numField; i < il; i++ {
+		// the values of the columns built by buildInsertColumns
+		writable := getStructColumns(vType).writable
+		values := getValExprListWithSize(len(writable)) //make([]condExpr, numField)
+		for i, col := range writable {
 			// Get value
-			fieldValue := v.Field(i)
+			fieldValue := v.Field(col.Index)
 			values.SetIth(i, db.Para, valueInterface(fieldValue, false))
 			// switch fieldValue.Kind() {
 			// default:
@@ -381,7 +532,7 @@ func (stmt *Stmt) IntoColumns(column any, cols ...string) *Stmt {
 	case Map:
 		stmt.buildInsertColsByMap(column)
 	default:
-		buildColumns(&stmt.InsertCols, column)
+		buildInsertColumns(&stmt.InsertCols, column)
 	}
 	return stmt
 }
@@ -424,7 +575,7 @@ func (stmt *Stmt) ValuesOne(data any) *Stmt {
 		stmt.From(curData)
 	default:
 		if len(stmt.InsertCols) == 0 {
-			buildColumns(&stmt.InsertCols, curData)
+			buildInsertColumns(&stmt.InsertCols, curData)
 		}
 		insertValues := buildValues(curData)
 		if insertValues != nil {
@@ -456,7 +607,7 @@ func (stmt *Stmt) valuesBulkInternal(data *reflect.Value) *Stmt {
 	case Map:
 		stmt.buildInsertColsByMap(data0)
 	default:
-		buildColumns(&stmt.InsertCols, data0)
+		buildInsertColumns(&stmt.InsertCols, data0)
 	}
 
 	// loading the data
@@ -511,18 +662,44 @@ func (stmt *Stmt) SelectColumns(column any, cols ...string) *Stmt {
 	return stmt
 }
 
-// From sets from subject(can be a table name in string or a builder pointer) and its alias
-func (stmt *Stmt) From(subject any, alias ...string) *Stmt {
-	var from fromItem
-	switch subject := subject.(type) {
-	case *Stmt:
-		//subquery should be a select statement, and we only accept one select stmt
-		from = createFromStmt(subject, "")
-	case Table:
-		from = createFromTable(subject.GetTableName(), "")
+// Distinct generate "SELECT DISTINCT cols" statement
+func (stmt *Stmt) Distinct() *Stmt {
+	stmt.distinct = true
+	return stmt
+}
+
+// DistinctOn generate "SELECT DISTINCT ON (cols) cols" statement, which keeps the
+// first row of each group of cols, e.g., the latest session per user. It is only
+// supported by Postgres, and cols must lead ORDER BY in any order if it is present.
+func (stmt *Stmt) DistinctOn(cols ...string) *Stmt {
+	stmt.distinct = true
+	stmt.distinctOn = append(stmt.distinctOn, cols...)
+	return stmt
+}
+
+// Returning generate "RETURNING cols" statement for insert, update and delete.
+// Like SelectColumns, it accepts column names or a struct to build the columns.
+// MySQL does not support RETURNING, so that ExecMySQL fetches the rows with an
+// extra SELECT, see emulateReturning for its limitations.
+func (stmt *Stmt) Returning(column any, cols ...string) *Stmt {
+	switch column := column.(type) {
+	case []string:
+		stmt.ReturningCols = append(stmt.ReturningCols, column...)
+	case Columns:
+		stmt.ReturningCols = append(stmt.ReturningCols, column...)
 	case string:
-		from = createFromTable(subject, "")
+		stmt.ReturningCols = append(stmt.ReturningCols, column)
+		stmt.ReturningCols = append(stmt.ReturningCols, cols...)
 	default:
+		buildColumns(&stmt.ReturningCols, column)
+	}
+	return stmt
+}
+
+// From sets from subject(can be a table name in string or a builder pointer) and its alias
+func (stmt *Stmt) From(subject any, alias ...string) *Stmt {
+	from := createFromItem(subject)
+	if from == nil {
 		return stmt
 	}
 
@@ -534,6 +711,66 @@ func (stmt *Stmt) From(subject any, alias ...string) *Stmt {
 	return stmt
 }
 
+// Using adds the source of "DELETE FROM target USING source" statement, which is the
+// same as From. Like "UPDATE target SET ... FROM source", the tables after the target
+// are the sources, which can be referred by the conditions. MySQL joins the sources,
+// i.e., "DELETE target FROM target JOIN source" and "UPDATE target JOIN source SET ...".
+func (stmt *Stmt) Using(subject any, alias ...string) *Stmt {
+	return stmt.From(subject, alias...)
+}
+
+// join appends a join clause of joinType on subject(can be a table name in string,
+// a Table or a builder pointer). The ON condition accepts the same input as Where.
+func (stmt *Stmt) join(joinType joinType, subject any, alias string, on any, args ...any) *Stmt {
+	item := createFromItem(subject)
+	if item == nil {
+		return stmt
+	}
+	item.setAliasName(alias)
+
+	join := createFromJoin(joinType, item)
+	if on != nil {
+		stmt.catCond(&join.on, &join.onRef, And, on, args...)
+	}
+
+	stmt.tableJoin = append(stmt.tableJoin, join)
+	return stmt
+}
+
+// Join generate "JOIN subject AS alias ON cond" statement
+func (stmt *Stmt) Join(subject any, alias string, on any, args ...any) *Stmt {
+	return stmt.join(joinDefault, subject, alias, on, args...)
+}
+
+// InnerJoin generate "INNER JOIN subject AS alias ON cond" statement
+func (stmt *Stmt) InnerJoin(subject any, alias string, on any, args ...any) *Stmt {
+	return stmt.join(joinInner, subject, alias, on, args...)
+}
+
+// LeftJoin generate "LEFT JOIN subject AS alias ON cond" statement
+func (stmt *Stmt) LeftJoin(subject any, alias string, on any, args ...any) *Stmt {
+	return stmt.join(joinLeft, subject, alias, on, args...)
+}
+
+// RightJoin generate "RIGHT JOIN subject AS alias ON cond" statement
+func (stmt *Stmt) RightJoin(subject any, alias string, on any, args ...any) *Stmt {
+	return stmt.join(joinRight, subject, alias, on, args...)
+}
+
+// FullJoin generate "FULL JOIN subject AS alias ON cond" statement, which is not supported by MySQL
+func (stmt *Stmt) FullJoin(subject any, alias string, on any, args ...any) *Stmt {
+	return stmt.join(joinFull, subject, alias, on, args...)
+}
+
+// CrossJoin generate "CROSS JOIN subject AS alias" statement
+func (stmt *Stmt) CrossJoin(subject any, alias ...string) *Stmt {
+	var aliasName string
+	if len(alias) > 0 {
+		aliasName = alias[0]
+	}
+	return stmt.join(joinCross, subject, aliasName, nil)
+}
+
 // Insert SQL
 func (stmt *Stmt) Insert(data ...any) *Stmt {
 	switch len(data) {
@@ -638,13 +875,15 @@ func (stmt *Stmt) Select(data ...any) *Stmt {
 
 // Incr Generate  "Update ... Set column = column + arg" statement
 func (stmt *Stmt) Incr(col string, args ...any) *Stmt {
-	stmt.SetCols.appendInc(col, args...)
+	stmt.keepCols(col)
+	stmt.assigns.appendInc(col, args...)
 	return stmt
 }
 
 // Decr Generate  "Update ... Set column = column - arg" statement
 func (stmt *Stmt) Decr(col string, args ...any) *Stmt {
-	stmt.SetCols.appendDec(col, args...)
+	stmt.keepCols(col)
+	stmt.assigns.appendDec(col, args...)
 	return stmt
 }
 
@@ -652,20 +891,19 @@ func (stmt *Stmt) Decr(col string, args ...any) *Stmt {
 // if you want to use writeTo internal builtin functions without parameters like NOW(),
 // then you'd better to call Set(col, Expr("Now()"))
 // Todo support expr as SQLStmt
-func (stmt *Stmt) setExpr(col string, expr any, args ...any) *Stmt {
+func setExpr(setCols *setList, col string, expr any, args ...any) {
 	switch e := expr.(type) {
 	case string:
 		if len(args) > 0 {
 			// set("col", "col||??", "test") => writeTo: col = col||??, args: "test"
-			stmt.SetCols.appendSet(col, e, args...)
-			return stmt
+			setCols.appendSet(col, e, args...)
+			return
 		}
 	case *condExpr:
-		stmt.SetCols.appendSet(col, e.String(), e.args...)
-		return stmt
+		setCols.appendSet(col, e.String(), e.args...)
+		return
 	}
-	stmt.SetCols.appendEq(col, expr)
-	return stmt
+	setCols.appendEq(col, expr)
 }
 
 // setMap Generate  "Update ... Set col1 = {expr1}, col1 = {expr2}" statement
@@ -673,35 +911,33 @@ func (stmt *Stmt) setExpr(col string, expr any, args ...any) *Stmt {
 // SQL: username = ?? , age = ??, createTime = NOW()
 // Args: ["bob", 10]
 // Todo support expr as SQLStmt
-func (stmt *Stmt) setMap(exprs Map) *Stmt {
+func setMap(setCols *setList, exprs Map) {
 	// avoid extend the slice cap which causes memory reallocation
 	for col, val := range exprs {
 		if e, ok := val.(*condExpr); ok {
-			stmt.SetCols.appendSet(col, e.String(), e.args...)
+			setCols.appendSet(col, e.String(), e.args...)
 		} else {
-			stmt.SetCols.appendEq(col, val)
+			setCols.appendEq(col, val)
 		}
 	}
-	return stmt
 }
 
-func (stmt *Stmt) setStruct(data any) *Stmt {
+func setStruct(setCols *setList, data any) {
 	// check whether data is struct
 	// reflect the exact value of the data regardless of whether it's a ptr or struct
 	v := util.ReflectValue(data)
 	vType := v.Type()
 	if vType.Kind() == reflect.Struct {
 
-		var colNames []string = buildColumnsInternal(v, vType)
-		numField := v.NumField()
 		// avoid extend the slice cap which causes memory reallocation
-		for i, il := 0, numField; i < il; i++ {
-			// Get column name, tag start with "pg" or the field Name
-			colName := colNames[i]
-
+		for _, col := range getStructColumns(vType).writable {
 			// Get value
-			fieldValue := v.Field(i)
-			stmt.SetCols.appendEq(colName, valueInterface(fieldValue, false))
+			fieldValue := v.Field(col.Index)
+			// the zero value of the omitempty column is not set
+			if col.OmitEmpty && fieldValue.IsZero() {
+				continue
+			}
+			setCols.appendEq(col.Name, valueInterface(fieldValue, false))
 			// switch fieldValue.Kind() {
 			// default:
 			// 	stmt.SetCols.addParam(colName, Expr(db.Para, valueInterface(fieldValue, false)))
@@ -720,24 +956,30 @@ func (stmt *Stmt) setStruct(data any) *Stmt {
 			// }
 		}
 	}
-	return stmt
 }
 
-func (stmt *Stmt) Set(data any, args ...any) *Stmt {
+// setData appends "col = {expr}" pairs built from data into setCols. data can be
+// a column name followed by its value or expression, a Map, a *condExpr or a struct.
+func setData(setCols *setList, data any, args ...any) {
 	switch data := data.(type) {
 	case string:
 		argLen := len(args)
 		if argLen >= 1 {
-			stmt.setExpr(data, args[0], args[1:]...)
+			setExpr(setCols, data, args[0], args[1:]...)
 		}
 	case Map:
-		stmt.setMap(data)
+		setMap(setCols, data)
 	case *condExpr:
-		stmt.SetCols.appendExpr(data)
+		setCols.appendExpr(data)
 	default:
 		// assume the input is either a struct ptr or a struct
-		stmt.setStruct(data)
+		setStruct(setCols, data)
 	}
+}
+
+func (stmt *Stmt) Set(data any, args ...any) *Stmt {
+	stmt.keepSetData(data)
+	setData(stmt.assigns, data, args...)
 	return stmt
 }
 
@@ -772,6 +1014,9 @@ func (stmt *Stmt) catCond(c *Cond, ref *[]Cond, OpFunc func(cond ...Cond) Cond,
 		if _, ok := (*c).(condEmpty); !ok {
 			*conds = append(*conds, *c)
 		}
+		for col := range query {
+			stmt.keepCols(col)
+		}
 		catCondMap(ref, query, conds)
 		*c = OpFunc(*conds...)
 		if len(*conds) >= 2 {
@@ -786,9 +1031,11 @@ func (stmt *Stmt) catCond(c *Cond, ref *[]Cond, OpFunc func(cond ...Cond) Cond,
 			*conds = append(*conds, *c)
 		}
 		*conds = append(*conds, query)
+		refSubConds(ref, query)
 		for _, v := range args {
 			if vv, ok := v.(Cond); ok {
 				*conds = append(*conds, vv)
+				refSubConds(ref, vv)
 			}
 		}
 		*c = OpFunc(*conds...)
@@ -821,13 +1068,12 @@ func (stmt *Stmt) GroupBy(keys ...string) *Stmt {
 		return stmt
 	}
 
-	groupByStr := stmt.GroupByStr
-
-	if groupByStr.Len() > 0 {
-		groupByStr.WriteString(", ")
+	stmt.keepCols(keys...)
+	if stmt.GroupByStr.Len() > 0 {
+		stmt.GroupByStr.WriteString(", ")
 	}
-
-	bufferJoin(groupByStr, keys, ", ")
+	bufferJoin(stmt.GroupByStr, keys, ", ")
+	stmt.groupBy = append(stmt.groupBy, keys...)
 	return stmt
 }
 
@@ -870,6 +1116,7 @@ func (stmt *Stmt) OrderBy(order ...string) *Stmt {
 	}
 
 	bufferJoin(orderByStr, order, ", ")
+	stmt.appendOrders(order)
 	return stmt
 }
 
@@ -886,6 +1133,9 @@ func (stmt *Stmt) Desc(colNames ...string) *Stmt {
 
 	bufferJoin(orderByStr, colNames, " DESC, ")
 	orderByStr.WriteString(" DESC")
+	for _, col := range colNames {
+		stmt.orders = append(stmt.orders, orderItem{col: col, desc: true})
+	}
 
 	return stmt
 }
@@ -903,6 +1153,9 @@ func (stmt *Stmt) Asc(colNames ...string) *Stmt {
 
 	bufferJoin(orderByStr, colNames, " ASC, ")
 	orderByStr.WriteString(" ASC")
+	for _, col := range colNames {
+		stmt.orders = append(stmt.orders, orderItem{col: col, desc: false})
+	}
 
 	return stmt
 }
@@ -0,0 +1,287 @@
+package sqlBuilderV3
+
+import (
+	"container/list"
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/secure-for-ai/secureai-microsvs/db/pgdb"
+)
+
+// The default limits of the statement caches, which are read when the cache of a
+// connection is created. Set them at start-up, or use StmtCache.SetLimits to change
+// the limits of a cache in use.
+var (
+	// StmtCacheSize is the maximum number of the statements prepared by the
+	// executors on a Postgres connection.
+	StmtCacheSize = 256
+	// StmtCacheMaxBytes is the approximate maximum size in bytes of the sql of the
+	// statements prepared on a Postgres connection. The sql larger than it, e.g.,
+	// a chunk of a multi-row insertion, is run without the cache.
+	StmtCacheMaxBytes = 4 << 20
+)
+
+// stmtSeq numbers the names of the prepared statements
+var stmtSeq atomic.Uint64
+
+// stmtCaches is the statement caches of the connections
+var stmtCaches = sync.Map{}
+
+// StmtCacheStats is the counters of the statement caches
+type StmtCacheStats struct {
+	Hits      int64
+	Misses    int64
+	Evictions int64
+	// Len is the number of the cached statements
+	Len int
+	// Bytes is the size of the sql of the cached statements
+	Bytes int
+}
+
+// StmtCache is the LRU cache of the statements prepared on a Postgres connection,
+// which is bounded by StmtCacheSize and StmtCacheMaxBytes. The evicted statements
+// and the ones invalidated by a schema change are deallocated.
+type StmtCache struct {
+	mu       sync.Mutex
+	ll       *list.List
+	entries  map[string]*list.Element
+	stats    StmtCacheStats
+	size     int
+	maxBytes int
+}
+
+type stmtEntry struct {
+	sql  string
+	name string
+}
+
+// connOf returns the connection of q, which is either a connection or a transaction
+func connOf(q pgdb.PGQuerier) *pgx.Conn {
+	if c, ok := q.(interface{ Conn() *pgx.Conn }); ok {
+		return c.Conn()
+	}
+	return nil
+}
+
+// StmtCacheOf returns the statement cache of the connection of q, or nil if q
+// does not expose its connection.
+func StmtCacheOf(q pgdb.PGQuerier) *StmtCache {
+	conn := connOf(q)
+	if conn == nil {
+		return nil
+	}
+	if cache, ok := stmtCaches.Load(conn); ok {
+		return cache.(*StmtCache)
+	}
+
+	// drop the caches of the closed connections before adding a new one, so that
+	// the caches are bounded by the connections ever opened at the same time.
+	stmtCaches.Range(func(key, _ any) bool {
+		if key.(*pgx.Conn).IsClosed() {
+			stmtCaches.Delete(key)
+		}
+		return true
+	})
+
+	cache, _ := stmtCaches.LoadOrStore(conn, &StmtCache{
+		ll:       list.New(),
+		entries:  make(map[string]*list.Element),
+		size:     StmtCacheSize,
+		maxBytes: StmtCacheMaxBytes,
+	})
+	return cache.(*StmtCache)
+}
+
+// TotalStmtCacheStats sums the counters of the statement caches of the open
+// connections.
+func TotalStmtCacheStats() StmtCacheStats {
+	var total StmtCacheStats
+	stmtCaches.Range(func(_, cache any) bool {
+		stats := cache.(*StmtCache).Stats()
+		total.Hits += stats.Hits
+		total.Misses += stats.Misses
+		total.Evictions += stats.Evictions
+		total.Len += stats.Len
+		total.Bytes += stats.Bytes
+		return true
+	})
+	return total
+}
+
+// SetLimits changes the maximum number and the size in bytes of the statements
+// of the cache. The statements over the new limits are evicted on the next miss.
+func (c *StmtCache) SetLimits(size, maxBytes int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.size = size
+	c.maxBytes = maxBytes
+}
+
+// Stats returns the counters of the cache
+func (c *StmtCache) Stats() StmtCacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.stats
+}
+
+// prepare returns the name of the statement of sql, which is prepared on the
+// connection of q on a miss. The least recently used statements over the limits
+// are deallocated.
+func (c *StmtCache) prepare(q pgdb.PGQuerier, ctx context.Context, sql string) (string, error) {
+	c.mu.Lock()
+	if e, ok := c.entries[sql]; ok {
+		c.ll.MoveToFront(e)
+		c.stats.Hits++
+		c.mu.Unlock()
+		return e.Value.(*stmtEntry).name, nil
+	}
+	c.stats.Misses++
+	maxBytes := c.maxBytes
+	c.mu.Unlock()
+
+	// the sql is held by the reusable writer
+	sql = strings.Clone(sql)
+	if len(sql) > maxBytes {
+		return sql, nil
+	}
+
+	name := "sqlb_" + strconv.FormatUint(stmtSeq.Add(1), 36)
+	if _, err := q.Prepare(ctx, name, sql); err != nil {
+		return "", err
+	}
+
+	var evicted []string
+	c.mu.Lock()
+	c.entries[sql] = c.ll.PushFront(&stmtEntry{sql: sql, name: name})
+	c.stats.Len++
+	c.stats.Bytes += len(sql)
+	for c.stats.Len > 1 && (c.stats.Len > c.size || c.stats.Bytes > c.maxBytes) {
+		entry := c.ll.Remove(c.ll.Back()).(*stmtEntry)
+		delete(c.entries, entry.sql)
+		c.stats.Len--
+		c.stats.Bytes -= len(entry.sql)
+		c.stats.Evictions++
+		evicted = append(evicted, entry.name)
+	}
+	c.mu.Unlock()
+
+	for _, name := range evicted {
+		// deallocate is a protocol message, which works in a failed transaction
+		// as well. It is not canceled with the query, as the statement is already
+		// dropped from the cache.
+		_ = q.Deallocate(context.WithoutCancel(ctx), name)
+	}
+	return name, nil
+}
+
+// planInvalidated checks whether err fails the prepared statement rather than the
+// query, i.e., "cached plan must not change result type" (0A000) after a schema
+// change, or the statement is missing on the connection (26000). The other errors,
+// e.g., a unique violation, keep the statement.
+func planInvalidated(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && (pgErr.Code == "0A000" || pgErr.Code == "26000")
+}
+
+// invalidate drops the statement of name from the cache and deallocates it if err
+// invalidates its plan, so that the next execution prepares the sql again. The
+// cache is searched linearly, as it only happens after a schema change.
+func (c *StmtCache) invalidate(q pgdb.PGQuerier, ctx context.Context, name string, err error) {
+	if !planInvalidated(err) {
+		return
+	}
+
+	found := false
+	c.mu.Lock()
+	for e := c.ll.Front(); e != nil; e = e.Next() {
+		entry := e.Value.(*stmtEntry)
+		if entry.name == name {
+			c.ll.Remove(e)
+			delete(c.entries, entry.sql)
+			c.stats.Len--
+			c.stats.Bytes -= len(entry.sql)
+			found = true
+			break
+		}
+	}
+	c.mu.Unlock()
+
+	if found {
+		// the statement is dropped from the cache, so it must be deallocated even
+		// if the query is canceled
+		_ = q.Deallocate(context.WithoutCancel(ctx), name)
+	}
+}
+
+// stmtRows is the rows of a cached statement, which invalidates the statement if
+// the query fails on its plan.
+type stmtRows struct {
+	pgx.Rows
+	q      pgdb.PGQuerier
+	ctx    context.Context
+	name   string
+	cache  *StmtCache
+	closed bool
+}
+
+func (rows *stmtRows) Close() {
+	rows.Rows.Close()
+	if rows.closed {
+		return
+	}
+	rows.closed = true
+	if err := rows.Rows.Err(); err != nil {
+		rows.cache.invalidate(rows.q, rows.ctx, rows.name, err)
+	}
+}
+
+// queryStmt runs the query of the statement name returned by prepareSQL. The
+// statement is invalidated if the query fails on its plan.
+func queryStmt(q pgdb.PGQuerier, ctx context.Context, name string, args ...any) (pgx.Rows, error) {
+	cache := StmtCacheOf(q)
+	rows, err := q.Query(ctx, name, args...)
+	if cache == nil {
+		return rows, err
+	}
+	if err != nil {
+		cache.invalidate(q, ctx, name, err)
+		return rows, err
+	}
+	return &stmtRows{Rows: rows, q: q, ctx: ctx, name: name, cache: cache}, nil
+}
+
+// execStmt executes the statement name returned by prepareSQL, and returns the
+// number of the affected rows. The statement is invalidated if it fails on its plan.
+func execStmt(q pgdb.PGQuerier, ctx context.Context, name string, args ...any) (int64, error) {
+	n, err := q.ExecRowsAffected(ctx, name, args...)
+	if err != nil {
+		invalidateStmt(q, ctx, name, err)
+	}
+	return n, err
+}
+
+// invalidateStmt invalidates the statement name in the cache of the connection of q
+// if err fails its plan
+func invalidateStmt(q pgdb.PGQuerier, ctx context.Context, name string, err error) {
+	if cache := StmtCacheOf(q); cache != nil {
+		cache.invalidate(q, ctx, name, err)
+	}
+}
+
+// prepareSQL prepares sql through the statement cache of the connection of q,
+// and returns the name of the statement to run. The sql is returned as is if q
+// does not expose its connection, which is cached by pgx instead.
+func prepareSQL(q pgdb.PGQuerier, ctx context.Context, sql string) (string, error) {
+	cache := StmtCacheOf(q)
+	if cache == nil {
+		// the sql is held by the reusable writer
+		return strings.Clone(sql), nil
+	}
+	return cache.prepare(q, ctx, sql)
+}
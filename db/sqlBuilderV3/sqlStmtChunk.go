@@ -0,0 +1,110 @@
+package sqlBuilderV3
+
+import (
+	"github.com/secure-for-ai/secureai-microsvs/db"
+	"github.com/secure-for-ai/secureai-microsvs/util"
+)
+
+// ChunkByteBudget is the approximate maximum size in bytes of a multi-row insertion,
+// including the SQL and the args of strings and bytes. The default value fits the
+// default max_allowed_packet of MySQL 5.7.
+var ChunkByteBudget = 4 << 20
+
+// maxParams returns the maximum number of the bind parameters of a statement
+func maxParams(schema db.Schema) int {
+	if schema == db.SchSQLite {
+		// SQLITE_MAX_VARIABLE_NUMBER since SQLite 3.32.0
+		return 32766
+	}
+	return 65535
+}
+
+// MultiRow generate "INSERT ... VALUES (...),(...),..." for the bulk insertion in
+// Postgres instead of inserting the rows one by one in a batch. MySQL and SQLite
+// always insert the rows in this way. The executors split the rows into chunks,
+// so that no chunk exceeds the bind parameter limit of the dialect or ChunkByteBudget,
+// and run the chunks in order.
+func (stmt *Stmt) MultiRow() *Stmt {
+	stmt.multiRow = true
+	return stmt
+}
+
+// multiRowValues checks whether the rows are inserted in a single statement
+func (stmt *Stmt) multiRowValues(schema db.Schema) bool {
+	return stmt.multiRow || schema == db.SchMYSQL || schema == db.SchSQLite
+}
+
+// rowSize returns the number of args and the approximate size in bytes of a row
+func rowSize(values *valExprList) (int, int) {
+	// "(", ")" and ","
+	args, size := 0, 3
+	for i := range *values {
+		value := &(*values)[i]
+		// the placeholder may grow from "??" to "$65535"
+		size += len(value.sql) + 5
+		args += len(value.args)
+		for _, arg := range value.args {
+			switch arg := arg.(type) {
+			case string:
+				size += len(arg)
+			case []byte:
+				size += len(arg)
+			default:
+				size += 8
+			}
+		}
+	}
+	return args, size
+}
+
+// execChunks generates the multi-row insertion chunk by chunk, and runs them
+// in order by exec. It stops at the first failed chunk, and the error is returned
+// in util.MultiError. The affected rows of the finished chunks are kept.
+func (stmt *Stmt) execChunks(schema db.Schema, exec func(query string, args []any) (int64, error)) (int64, error) {
+	rows := stmt.InsertValues
+	defer func() {
+		stmt.InsertValues = rows
+	}()
+
+	w := NewWriter()
+	defer w.Destroy()
+
+	// find the args and the size shared by every chunk, e.g., the ones of WITH
+	// and ON CONFLICT, from the statement of the first row.
+	stmt.InsertValues = rows[:1]
+	query, args, err := stmt.Gen(w, schema)
+	if err != nil {
+		return 0, err
+	}
+	rowArgs, rowBytes := rowSize(rows[0])
+	maxArgs := maxParams(schema) - (len(args) - rowArgs)
+	maxBytes := ChunkByteBudget - (len(query) - rowBytes)
+
+	var affectedRows int64 = 0
+	for start := 0; start < len(rows); {
+		end, chunkArgs, chunkBytes := start, 0, 0
+		for ; end < len(rows); end++ {
+			rowArgs, rowBytes = rowSize(rows[end])
+			// a chunk has one row at least
+			if end > start && (chunkArgs+rowArgs > maxArgs || chunkBytes+rowBytes > maxBytes) {
+				break
+			}
+			chunkArgs += rowArgs
+			chunkBytes += rowBytes
+		}
+
+		stmt.InsertValues = rows[start:end]
+		query, args, err = stmt.Gen(w, schema)
+		if err == nil {
+			var rowsAffected int64
+			rowsAffected, err = exec(query, args)
+			affectedRows += rowsAffected
+		}
+		if err != nil {
+			return affectedRows, util.MultiError{err}
+		}
+		start = end
+	}
+
+	return affectedRows, nil
+}
@@ -0,0 +1,135 @@
+package sqlBuilderV3
+
+import (
+	"context"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/secure-for-ai/secureai-microsvs/db"
+	"github.com/secure-for-ai/secureai-microsvs/db/pgdb"
+	"github.com/secure-for-ai/secureai-microsvs/util"
+)
+
+// CopyThreshold is the minimum number of rows for ExecPG to insert the rows through
+// COPY instead of a batch of insertions. COPY is disabled if it is not positive,
+// which is the default, as COPY inserts all the rows or none of them, while the
+// batch reports the error of every row and inserts the others.
+var CopyThreshold = 0
+
+// canCopy checks whether the rows can be inserted through COPY, which requires the
+// plain column values without any expression. COPY cannot handle ON CONFLICT,
+// RETURNING and WITH either.
+func (stmt *Stmt) canCopy() bool {
+	if CopyThreshold <= 0 || len(stmt.InsertValues) < CopyThreshold || len(stmt.InsertValues) < 2 ||
+		len(stmt.InsertCols) == 0 || len(stmt.tableFrom) > 0 || len(stmt.with) > 0 ||
+		len(stmt.ReturningCols) > 0 || stmt.conflict.isValid() {
+		return false
+	}
+
+	for _, values := range stmt.InsertValues {
+		if len(*values) != len(stmt.InsertCols) {
+			return false
+		}
+		for i := range *values {
+			value := &(*values)[i]
+			if value.sql != db.Para || len(value.args) != 1 {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// copyPG inserts the rows through COPY. Like the batch, the error is returned
+// in util.MultiError. As COPY is atomic, no row is inserted if it fails.
+func (stmt *Stmt) copyPG(tx pgdb.PGQuerier, ctx context.Context) (int64, error) {
+	// the statement rejected by Gen is never copied
+	if stmt.err != nil {
+		return 0, stmt.err
+	}
+	if err := stmt.strictCheck(); err != nil {
+		return 0, err
+	}
+
+	cols := make([]string, len(stmt.InsertCols))
+	for i, col := range stmt.InsertCols {
+		cols[i] = strings.Join(copyIdent(col), ".")
+	}
+
+	src := copyFromValues{rows: stmt.InsertValues, i: -1}
+	rowsAffected, err := tx.CopyFrom(ctx, copyIdent(stmt.tableInto), cols, &src)
+	if err != nil {
+		return rowsAffected, util.MultiError{err}
+	}
+	return rowsAffected, nil
+}
+
+// copyIdent converts the name written in SQL, e.g., Users or public."Users", into the
+// identifier quoted by CopyFrom. Like Postgres, the unquoted parts are folded to lower
+// case, so that COPY writes the same table and columns as the insertion.
+func copyIdent(name string) pgx.Identifier {
+	var ident pgx.Identifier
+	for len(name) > 0 {
+		var part string
+		if name[0] == '"' {
+			// the doubled quote escapes itself
+			var b strings.Builder
+			i := 1
+			for ; i < len(name); i++ {
+				if name[i] == '"' {
+					if i+1 < len(name) && name[i+1] == '"' {
+						b.WriteByte('"')
+						i++
+						continue
+					}
+					break
+				}
+				b.WriteByte(name[i])
+			}
+			part, name = b.String(), name[min(i+1, len(name)):]
+		} else {
+			i := strings.IndexByte(name, '.')
+			if i < 0 {
+				i = len(name)
+			}
+			// only the ASCII letters are folded, just like Postgres
+			part = strings.Map(func(r rune) rune {
+				if 'A' <= r && r <= 'Z' {
+					return r + 'a' - 'A'
+				}
+				return r
+			}, name[:i])
+			name = name[i:]
+		}
+		ident = append(ident, part)
+		name = strings.TrimPrefix(name, ".")
+	}
+	return ident
+}
+
+// copyFromValues implements pgx.CopyFromSource over the rows of the insertion
+type copyFromValues struct {
+	rows   valExpr2DList
+	i      int
+	values []any
+}
+
+var _ pgx.CopyFromSource = &copyFromValues{}
+
+func (src *copyFromValues) Next() bool {
+	src.i++
+	return src.i < len(src.rows)
+}
+
+func (src *copyFromValues) Values() ([]any, error) {
+	// the values are encoded before the next row, so the slice is reused
+	src.values = src.values[:0]
+	for _, value := range *src.rows[src.i] {
+		src.values = append(src.values, value.args[0])
+	}
+	return src.values, nil
+}
+
+func (src *copyFromValues) Err() error {
+	return nil
+}
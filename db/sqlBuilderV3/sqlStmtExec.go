@@ -2,12 +2,8 @@ package sqlBuilderV3
 
 import (
 	"context"
-	"crypto"
-	"encoding/hex"
 	"errors"
 	"reflect"
-	"strings"
-	"sync"
 
 	"github.com/jackc/pgx/v5"
 	"github.com/secure-for-ai/secureai-microsvs/db"
@@ -15,24 +11,42 @@ import (
 	"github.com/secure-for-ai/secureai-microsvs/util"
 )
 
-var sqlCache = sync.Map{}
-
 func (stmt *Stmt) ExecPG(tx pgdb.PGQuerier, ctx context.Context, result ...any) (int64, error) {
+	// Insert a large number of rows through COPY
+	if stmt.sqlType == InsertType && stmt.canCopy() {
+		return stmt.copyPG(tx, ctx)
+	}
+
+	// RETURNING rows are scanned into the result just like selects
+	returning := len(result) > 0 && len(stmt.ReturningCols) > 0
+
+	// Insert multiple rows in chunks of multi-row insertions
+	if stmt.sqlType == InsertType && stmt.multiRow && len(stmt.tableFrom) == 0 && len(stmt.InsertValues) > 1 {
+		rows := len(stmt.InsertValues)
+		return stmt.execChunks(db.SchPG, func(sql string, args []any) (int64, error) {
+			analyzeQuery(tx, ctx, sql, args...)
+			name, err := prepareSQL(tx, ctx, sql)
+			if err != nil {
+				return 0, err
+			}
+			if returning {
+				return queryPG(tx, ctx, rows, result[0], name, args...)
+			}
+			return execStmt(tx, ctx, name, args...)
+		})
+	}
+
 	w := NewWriter()
 	defer w.Destroy()
 	sql, args, err := stmt.Gen(w, db.SchPG)
 
-	// Get the sql from the cache as the sql is hold by w, which is a reusable buffer
-	// pgx need to store sql in its local cache, therefore, we need to make a deep copy
-	// of the sql.
-	if val, ok := sqlCache.Load(sql); ok {
-		sql = val.(string)
-	} else {
-		sql = strings.Clone(sql)
-		sqlCache.Store(sql, sql)
+	// there is an error in query generation.
+	if err != nil {
+		return 0, err
 	}
 
-	// there is an error in query generation.
+	// the prepared statement of sql
+	name, err := prepareSQL(tx, ctx, sql)
 	if err != nil {
 		return 0, err
 	}
@@ -42,29 +56,20 @@ func (stmt *Stmt) ExecPG(tx pgdb.PGQuerier, ctx context.Context, result ...any)
 		// Insert Select or Insert one record
 		if len(stmt.tableFrom) > 0 || len(stmt.InsertValues) == 1 {
 			analyzeQuery(tx, ctx, sql, args...)
-			return tx.ExecRowsAffected(ctx, sql, args...)
-		}
-
-		// Insert multiple rows
-
-		// ts := strconv.FormatInt(util.GetNowTimestamp(), 10)
-		// nonce, _ := util.GenerateRandomKey(8)
-		// sqlName := util.Base64EncodeToString(nonce) + ts
-
-		sqlName := "sql_" + hex.EncodeToString(util.HashString(sql, crypto.SHA256))
-
-		_, err := tx.Prepare(ctx, sqlName, sql)
-		if err != nil {
-			return 0, err
+			if returning {
+				return queryPG(tx, ctx, stmt.LimitN, result[0], name, args...)
+			}
+			return execStmt(tx, ctx, name, args...)
 		}
 
+		// Insert multiple rows through the prepared statement in a batch
 		batch := &pgx.Batch{}
 
 		bulkArgs := w.BulkArgs()
 		rows := len(bulkArgs)
 		for _, args := range bulkArgs {
 			analyzeQuery(tx, ctx, sql, *args...)
-			batch.Queue(sqlName, *args...)
+			batch.Queue(name, *args...)
 		}
 
 		br := tx.SendBatch(context.Background(), batch)
@@ -73,6 +78,23 @@ func (stmt *Stmt) ExecPG(tx pgdb.PGQuerier, ctx context.Context, result ...any)
 		var errs util.MultiError
 
 		for i := 0; i < rows; i++ {
+			if returning {
+				// every row is scanned into the result, a slice result
+				// collects all the returned rows.
+				bRows, err := br.Query()
+				if err != nil {
+					bRows.Close()
+					errs = append(errs, err)
+					continue
+				}
+				rowsAffected, err := scanPG(bRows, rows, result[0])
+				if err != nil {
+					errs = append(errs, err)
+				}
+				affectedRows += rowsAffected
+				continue
+			}
+
 			tag, err := br.Exec()
 			if err != nil {
 				errs = append(errs, err)
@@ -89,57 +111,85 @@ func (stmt *Stmt) ExecPG(tx pgdb.PGQuerier, ctx context.Context, result ...any)
 		if len(errs) == 0 {
 			return affectedRows, nil
 		}
+		for _, err := range errs {
+			invalidateStmt(tx, ctx, name, err)
+		}
 		return affectedRows, errs
 	case DeleteType, UpdateType:
 		analyzeQuery(tx, ctx, sql, args...)
-		return tx.ExecRowsAffected(ctx, sql, args...)
-	case SelectType:
-		analyzeQuery(tx, ctx, sql, args...)
-		rows, err := tx.Query(ctx, sql, args...)
-
-		if err != nil {
-			return 0, err
+		if returning {
+			return queryPG(tx, ctx, stmt.LimitN, result[0], name, args...)
 		}
+		return execStmt(tx, ctx, name, args...)
+	case SelectType, CompoundType:
+		analyzeQuery(tx, ctx, sql, args...)
 
 		// result is not given, so do nothing
 		if len(result) == 0 {
+			rows, err := queryStmt(tx, ctx, name, args...)
+			if err != nil {
+				return 0, err
+			}
 			rows.Close()
-			return rows.CommandTag().RowsAffected(), err
+			return rows.CommandTag().RowsAffected(), rows.Err()
 		}
 
-		res := result[0]
-		resValue := util.ReflectValue(res)
-
-		switch resValue.Kind() {
-		case reflect.Struct:
-			err = pgdb.StructScanOne(rows, result[0])
-		case reflect.Slice:
-			// if the data type of result[0] is a slice, then pre-allocate
-			// the memory up to stmt.LimitN slots in case of resValue.Cap() < stmt.LimitN
-			if resValue.Cap() < stmt.LimitN {
-				resValue.Set(reflect.MakeSlice(resValue.Type(), 0, stmt.LimitN))
-			}
+		return queryPG(tx, ctx, stmt.LimitN, result[0], name, args...)
+	default:
+		return 0, ErrNotSupportType
+	}
+}
 
-			// handle map scan
-			if maps, ok := res.(*[]map[string]any); ok {
-				err = pgdb.PGMapScan(rows, maps)
-				goto RowClose
-			}
+// queryPG runs the query of the statement returned by prepareSQL and scans the
+// returned rows into res
+func queryPG(tx pgdb.PGQuerier, ctx context.Context, limit int, res any, name string, args ...any) (int64, error) {
+	rows, err := queryStmt(tx, ctx, name, args...)
 
-			// handle array scan
-			if arr, ok := res.(*[][]any); ok {
-				err = pgdb.PGArrayScan(rows, arr)
-				goto RowClose
-			}
-			err = pgdb.StructScanSlice(rows, result[0])
-		default:
-			err = errors.New("not support result data type: " + reflect.TypeOf(result[0]).String())
-			rows.Close()
+	if err != nil {
+		return 0, err
+	}
+
+	return scanPG(rows, limit, res)
+}
+
+// scanPG scans rows into res, which can be a struct, a slice of struct, *[]map[string]any
+// or *[][]any. rows is always closed afterwards. It returns the number of affected rows,
+// and the error of running the query, e.g., a unique violation of INSERT ... RETURNING,
+// which is only reported by rows.Err.
+func scanPG(rows pgx.Rows, limit int, res any) (int64, error) {
+	var err error
+	resValue := util.ReflectValue(res)
+
+	switch resValue.Kind() {
+	case reflect.Struct:
+		err = structScanOne(rows, res)
+	case reflect.Slice:
+		// if the data type of res is a slice, then pre-allocate
+		// the memory up to limit slots in case of resValue.Cap() < limit
+		if limit = resultCap(limit); resValue.Cap() < limit {
+			resValue.Set(reflect.MakeSlice(resValue.Type(), 0, limit))
 		}
 
-	RowClose:
-		return rows.CommandTag().RowsAffected(), err
+		// handle map scan
+		if maps, ok := res.(*[]map[string]any); ok {
+			err = pgdb.PGMapScan(rows, maps)
+			goto RowClose
+		}
+
+		// handle array scan
+		if arr, ok := res.(*[][]any); ok {
+			err = pgdb.PGArrayScan(rows, arr)
+			goto RowClose
+		}
+		err = pgdb.StructScanSlice(rows, res)
 	default:
-		return 0, ErrNotSupportType
+		err = errors.New("not support result data type: " + reflect.TypeOf(res).String())
+	}
+
+RowClose:
+	rows.Close()
+	if rowsErr := rows.Err(); rowsErr != nil && err == nil {
+		err = rowsErr
 	}
+	return rows.CommandTag().RowsAffected(), err
 }
@@ -0,0 +1,253 @@
+package sqlBuilderV3
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/secure-for-ai/secureai-microsvs/db"
+	"github.com/secure-for-ai/secureai-microsvs/db/pgdb"
+	"github.com/secure-for-ai/secureai-microsvs/util"
+)
+
+// SQLQuerier is the common interface of *sql.DB, *sql.Tx and *sql.Conn
+type SQLQuerier interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+}
+
+var _ SQLQuerier = &sql.DB{}
+var _ SQLQuerier = &sql.Tx{}
+var _ SQLQuerier = &sql.Conn{}
+
+// ExecMySQL executes the statement on MySQL through database/sql
+func (stmt *Stmt) ExecMySQL(tx SQLQuerier, ctx context.Context, result ...any) (int64, error) {
+	return stmt.ExecSQL(tx, ctx, db.SchMYSQL, result...)
+}
+
+// ExecSQLite executes the statement on SQLite through database/sql
+func (stmt *Stmt) ExecSQLite(tx SQLQuerier, ctx context.Context, result ...any) (int64, error) {
+	return stmt.ExecSQL(tx, ctx, db.SchSQLite, result...)
+}
+
+// ExecSQL executes the statement through database/sql, and the SQL is generated
+// for the given dialect. Like ExecPG, the selected rows and the ones returned by
+// RETURNING are scanned into result[0], which can be a struct, a slice of struct,
+// *[]map[string]any or *[][]any. It returns the number of affected rows.
+//
+// For the dialect without array types, the args of slices, maps and structs are
+// stored as JSON, and the JSON columns are decoded into such struct fields.
+func (stmt *Stmt) ExecSQL(tx SQLQuerier, ctx context.Context, schema db.Schema, result ...any) (int64, error) {
+	returning := len(result) > 0 && len(stmt.ReturningCols) > 0
+
+	// Insert multiple rows in chunks of multi-row insertions
+	if stmt.sqlType == InsertType && stmt.multiRowValues(schema) && len(stmt.tableFrom) == 0 && len(stmt.InsertValues) > 1 {
+		rows := len(stmt.InsertValues)
+		return stmt.execChunks(schema, func(query string, args []any) (int64, error) {
+			if !supportArray(schema) {
+				if err := jsonArgs(args); err != nil {
+					return 0, err
+				}
+			}
+
+			switch {
+			case returning && !supportReturning(schema):
+				return stmt.emulateReturning(tx, ctx, schema, result[0], query, args...)
+			case returning:
+				return querySQL(tx, ctx, rows, result[0], query, args...)
+			}
+			return execSQL(tx, ctx, query, args...)
+		})
+	}
+
+	w := NewWriter()
+	defer w.Destroy()
+	query, args, err := stmt.Gen(w, schema)
+
+	// there is an error in query generation.
+	if err != nil {
+		return 0, err
+	}
+
+	if !supportArray(schema) {
+		if err = jsonArgs(args); err != nil {
+			return 0, err
+		}
+		for _, args := range w.BulkArgs() {
+			if err = jsonArgs(*args); err != nil {
+				return 0, err
+			}
+		}
+	}
+
+	switch stmt.sqlType {
+	case InsertType, DeleteType, UpdateType:
+		if returning && !supportReturning(schema) {
+			return stmt.emulateReturning(tx, ctx, schema, result[0], query, args...)
+		}
+
+		bulkArgs := w.BulkArgs()
+		if len(bulkArgs) == 0 {
+			if returning {
+				return querySQL(tx, ctx, stmt.LimitN, result[0], query, args...)
+			}
+			return execSQL(tx, ctx, query, args...)
+		}
+
+		// Insert multiple rows one by one, as the dialect writes a single row
+		// insertion for the bulk, e.g., Postgres through database/sql.
+		var affectedRows int64 = 0
+		var errs util.MultiError
+		for _, args := range bulkArgs {
+			var rowsAffected int64
+			if returning {
+				rowsAffected, err = querySQL(tx, ctx, len(bulkArgs), result[0], query, *args...)
+			} else {
+				rowsAffected, err = execSQL(tx, ctx, query, *args...)
+			}
+			if err != nil {
+				errs = append(errs, err)
+			}
+			affectedRows += rowsAffected
+		}
+
+		if len(errs) == 0 {
+			return affectedRows, nil
+		}
+		return affectedRows, errs
+	case SelectType, CompoundType:
+		// result is not given, so count the rows only
+		if len(result) == 0 {
+			rows, err := tx.QueryContext(ctx, query, args...)
+			if err != nil {
+				return 0, err
+			}
+			defer rows.Close()
+
+			var n int64 = 0
+			for rows.Next() {
+				n++
+			}
+			return n, rows.Err()
+		}
+
+		return querySQL(tx, ctx, stmt.LimitN, result[0], query, args...)
+	default:
+		return 0, ErrNotSupportType
+	}
+}
+
+// execSQL executes the statement, and returns the number of affected rows
+func execSQL(tx SQLQuerier, ctx context.Context, query string, args ...any) (int64, error) {
+	res, err := tx.ExecContext(ctx, query, args...)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+// querySQL runs the query and scans the returned rows into res
+func querySQL(tx SQLQuerier, ctx context.Context, limit int, res any, query string, args ...any) (int64, error) {
+	rows, err := tx.QueryContext(ctx, query, args...)
+	if err != nil {
+		return 0, err
+	}
+
+	return scanSQL(rows, limit, res)
+}
+
+// emulateReturning fetches the rows of RETURNING with an extra SELECT for the
+// dialect without RETURNING, i.e., MySQL.
+//
+//   - INSERT: the first returning column must be the AUTO_INCREMENT key. The rows
+//     are selected by the range of the keys starting from LAST_INSERT_ID(), which
+//     requires the keys of a multi-row insertion to be consecutive, i.e.,
+//     innodb_autoinc_lock_mode is 0 or 1. It is not supported by upserts.
+//   - UPDATE: the rows are selected by the same WHERE after the update, so the
+//     update should not change the columns used by WHERE.
+//   - DELETE: the rows are selected by the same WHERE before the deletion. The
+//     deletion runs even if no row is selected.
+//
+// The multi-table update and delete are not supported, as WHERE may refer the sources.
+//
+// Run the statement in a *sql.Tx to make it atomic.
+func (stmt *Stmt) emulateReturning(tx SQLQuerier, ctx context.Context, schema db.Schema,
+	res any, query string, args ...any) (int64, error) {
+	switch stmt.sqlType {
+	case InsertType:
+		if stmt.conflict.isValid() {
+			return 0, ErrNotSupportDialectFeature
+		}
+
+		r, err := tx.ExecContext(ctx, query, args...)
+		if err != nil {
+			return 0, err
+		}
+		rowsAffected, err := r.RowsAffected()
+		if err != nil || rowsAffected == 0 {
+			return rowsAffected, err
+		}
+		id, err := r.LastInsertId()
+		if err != nil {
+			return rowsAffected, err
+		}
+
+		key := stmt.ReturningCols[0]
+		sel := Select().SelectColumns(stmt.ReturningCols).From(stmt.tableInto).
+			Where(Between(key, id, id+rowsAffected-1)).Asc(key)
+		defer sel.Destroy()
+		_, err = sel.querySQL(tx, ctx, schema, int(rowsAffected), res)
+		return rowsAffected, err
+	case UpdateType:
+		if stmt.multiTable() {
+			return 0, ErrNotSupportDialectFeature
+		}
+		rowsAffected, err := execSQL(tx, ctx, query, args...)
+		if err != nil {
+			return rowsAffected, err
+		}
+		sel := stmt.returningSelect()
+		defer sel.releaseReturningSelect()
+		_, err = sel.querySQL(tx, ctx, schema, int(rowsAffected), res)
+		return rowsAffected, err
+	case DeleteType:
+		if stmt.multiTable() {
+			return 0, ErrNotSupportDialectFeature
+		}
+		sel := stmt.returningSelect()
+		defer sel.releaseReturningSelect()
+		// the struct result of no row is left as is, and the deletion still runs
+		if _, err := sel.querySQL(tx, ctx, schema, stmt.LimitN, res); err != nil && !errors.Is(err, pgdb.ErrFindNil) {
+			return 0, err
+		}
+		return execSQL(tx, ctx, query, args...)
+	}
+	return 0, ErrNotSupportType
+}
+
+// returningSelect creates "SELECT returning FROM table WHERE ..." which borrows
+// the table and the condition of the update or delete statement.
+func (stmt *Stmt) returningSelect() *Stmt {
+	sel := Select().SelectColumns(stmt.ReturningCols)
+	sel.tableFrom = append(sel.tableFrom, stmt.tableFrom[0])
+	sel.where = stmt.where
+	return sel
+}
+
+// releaseReturningSelect gives back the borrowed table and condition before
+// destroying the statement created by returningSelect.
+func (stmt *Stmt) releaseReturningSelect() {
+	stmt.tableFrom = stmt.tableFrom[:0]
+	stmt.where = condEmpty{}
+	stmt.Destroy()
+}
+
+func (stmt *Stmt) querySQL(tx SQLQuerier, ctx context.Context, schema db.Schema, limit int, res any) (int64, error) {
+	w := NewWriter()
+	defer w.Destroy()
+	query, args, err := stmt.Gen(w, schema)
+	if err != nil {
+		return 0, err
+	}
+	return querySQL(tx, ctx, limit, res, query, args...)
+}
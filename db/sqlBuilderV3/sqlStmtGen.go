@@ -1,6 +1,7 @@
 package sqlBuilderV3
 
 import (
+	"slices"
 	"strconv"
 	"strings"
 
@@ -10,16 +11,18 @@ import (
 func (stmt *Stmt) Gen(w *Writer, schema ...db.Schema) (string, []any, error) {
 	var err error
 	w.Reset()
+	if len(schema) > 0 {
+		w.schema = schema[0]
+	}
 
-	switch stmt.sqlType {
-	case InsertType:
-		err = stmt.insertWriteTo(w)
-	case DeleteType:
-		err = stmt.deleteWriteTo(w)
-	case UpdateType:
-		err = stmt.updateWriteTo(w)
-	case SelectType:
-		err = stmt.selectWriteTo(w)
+	if stmt.sqlType != RawType {
+		// never emit the partial SQL on error
+		if err = stmt.WriteTo(w); err == nil {
+			err = w.err
+		}
+		if err != nil {
+			return "", nil, err
+		}
 	}
 
 	sql := strings.Clone(w.String())
@@ -32,6 +35,10 @@ func (stmt *Stmt) Gen(w *Writer, schema ...db.Schema) (string, []any, error) {
 		return sql, w.args, err
 	}
 
+	if hasNamedArgs(w.args) || (len(w.bulkArgs) > 0 && hasNamedArgs(*w.bulkArgs[0])) {
+		return genNamed(sql, w)
+	}
+
 	//reset memory of the writer
 	w.stringWriter.Reset()
 	w.Grow(len(sql))
@@ -56,7 +63,7 @@ func (stmt *Stmt) Gen(w *Writer, schema ...db.Schema) (string, []any, error) {
 		switch schema[0] {
 		case db.SchPG:
 			callback = pgFunc
-		case db.SchMYSQL:
+		case db.SchMYSQL, db.SchSQLite:
 			w.Grow(len(sql) - len(w.args))
 		}
 	}
@@ -75,6 +82,18 @@ func (stmt *Stmt) Gen(w *Writer, schema ...db.Schema) (string, []any, error) {
 }
 
 func (stmt *Stmt) WriteTo(w *Writer) error {
+	if stmt.err != nil {
+		return stmt.err
+	}
+
+	if err := stmt.strictCheck(); err != nil {
+		return err
+	}
+
+	if err := stmt.withWriteTo(w); err != nil {
+		return err
+	}
+
 	switch stmt.sqlType {
 	case InsertType:
 		return stmt.insertWriteTo(w)
@@ -84,17 +103,19 @@ func (stmt *Stmt) WriteTo(w *Writer) error {
 		return stmt.updateWriteTo(w)
 	case SelectType:
 		return stmt.selectWriteTo(w)
+	case CompoundType:
+		return stmt.compoundWriteTo(w)
 	}
 	return ErrNotSupportType
 }
 
 func (stmt *Stmt) insertSelectWriteTo(w *Writer) error {
 	w.WriteString("INSERT INTO ")
-	w.WriteString(stmt.tableInto)
+	w.WriteIdent(stmt.tableInto)
 
 	if len(stmt.InsertCols) > 0 {
 		w.WriteString(" (")
-		w.Join(stmt.InsertCols, ',')
+		w.JoinIdent(stmt.InsertCols, ',')
 		w.WriteString(") ")
 	} else {
 		w.WriteByte(' ')
@@ -102,10 +123,15 @@ func (stmt *Stmt) insertSelectWriteTo(w *Writer) error {
 
 	if s, ok := stmt.tableFrom[0].(*fromStmt); ok {
 		s.writeTo(w)
-		return nil
+	} else if err := stmt.selectWriteTo(w); err != nil {
+		return err
 	}
 
-	return stmt.selectWriteTo(w)
+	if err := stmt.conflictWriteTo(w); err != nil {
+		return err
+	}
+	stmt.returningWriteTo(w)
+	return nil
 }
 
 func (stmt *Stmt) insertWriteTo(w *Writer) error {
@@ -119,11 +145,11 @@ func (stmt *Stmt) insertWriteTo(w *Writer) error {
 	}
 
 	w.WriteString("INSERT INTO ")
-	w.WriteString(stmt.tableInto)
+	w.WriteIdent(stmt.tableInto)
 
 	if len(stmt.InsertCols) > 0 {
 		w.WriteString(" (")
-		w.Join(stmt.InsertCols, ',')
+		w.JoinIdent(stmt.InsertCols, ',')
 		w.WriteString(") VALUES (")
 	} else {
 		w.WriteString(" VALUES (")
@@ -144,10 +170,30 @@ func (stmt *Stmt) insertWriteTo(w *Writer) error {
 			}
 		}
 	default:
-		// write the first row including sql concat
+		// insert all the rows in a single statement
+		if stmt.multiRowValues(w.schema) {
+			for i, values := range stmt.InsertValues {
+				if i > 0 {
+					w.WriteString("),(")
+				}
+				valuesLen := len(*values)
+				for j, value := range *values {
+					w.WriteString(value.String())
+					w.Append(value.args...)
+					if j != valuesLen-1 {
+						w.WriteByte(',')
+					}
+				}
+			}
+			break
+		}
+
+		// write the first row including sql concat. Every row of the bulk
+		// insertion shares the args written ahead, e.g., the args of WITH.
 		values := stmt.InsertValues[0]
 		valuesLen := len(*values)
 		args := getArgs()
+		*args = append(*args, w.args...)
 
 		for i, value := range *values {
 			w.WriteString(value.String())
@@ -161,6 +207,7 @@ func (stmt *Stmt) insertWriteTo(w *Writer) error {
 		// write the rest rows
 		for _, values := range stmt.InsertValues[1:] {
 			args := getArgs()
+			*args = append(*args, w.args...)
 			for _, value := range *values {
 				*args = append(*args, value.args...)
 			}
@@ -170,43 +217,144 @@ func (stmt *Stmt) insertWriteTo(w *Writer) error {
 
 	w.WriteByte(')')
 
+	argsLen := len(w.args)
+	if err := stmt.conflictWriteTo(w); err != nil {
+		return err
+	}
+	// the args of the upsert are shared by every row of the bulk insertion
+	if len(w.bulkArgs) > 0 {
+		for _, args := range w.bulkArgs {
+			*args = append(*args, w.args[argsLen:]...)
+		}
+		w.args = w.args[:0]
+	}
+	stmt.returningWriteTo(w)
+
 	return nil
 }
 
 func (stmt *Stmt) deleteWriteTo(w *Writer) error {
-	if len(stmt.tableFrom) <= 0 {
-		return ErrNoTableName
+	if err := stmt.targetCheck(); err != nil {
+		return err
 	}
 
-	w.WriteString("DELETE FROM ")
-	stmt.tableFrom[0].writeTo(w)
+	multiTable := stmt.multiTable()
+	if !multiTable {
+		w.WriteString("DELETE FROM ")
+		stmt.tableFrom[0].writeTo(w)
+	} else if w.schema == db.SchMYSQL {
+		// MySQL writes "DELETE t1 FROM t1 JOIN t2"
+		w.WriteString("DELETE ")
+		if from := stmt.tableFrom[0].(*fromTable); len(from.alias) > 0 {
+			w.WriteString(from.alias)
+		} else {
+			w.WriteIdent(from.tableName)
+		}
+		w.WriteString(" FROM ")
+		stmt.tableFrom[0].writeTo(w)
+		if err := stmt.sourceJoinWriteTo(w); err != nil {
+			return err
+		}
+	} else if w.schema == db.SchSQLite {
+		// SQLite has no DELETE ... USING
+		return ErrNotSupportDialectFeature
+	} else {
+		w.WriteString("DELETE FROM ")
+		stmt.tableFrom[0].writeTo(w)
+		w.WriteString(" USING ")
+		if err := stmt.sourceWriteTo(w); err != nil {
+			return err
+		}
+	}
 
 	if stmt.where.IsValid() {
 		w.WriteString(" WHERE ")
 		stmt.where.WriteTo(w)
 	}
 
+	stmt.returningWriteTo(w)
+
 	return nil
 }
 
 func (stmt *Stmt) updateWriteTo(w *Writer) error {
-	if len(stmt.tableFrom) <= 0 {
-		return ErrNoTableName
+	if err := stmt.targetCheck(); err != nil {
+		return err
 	}
 
+	multiTable := stmt.multiTable()
 	w.WriteString("UPDATE ")
 	stmt.tableFrom[0].writeTo(w)
+	// MySQL writes "UPDATE t1 JOIN t2 SET ..."
+	if multiTable && w.schema == db.SchMYSQL {
+		if err := stmt.sourceJoinWriteTo(w); err != nil {
+			return err
+		}
+	}
 	w.WriteString(" SET ")
-	stmt.SetCols.WriteTo(w)
+	stmt.assigns.WriteTo(w)
+
+	if multiTable && w.schema != db.SchMYSQL {
+		w.WriteString(" FROM ")
+		if err := stmt.sourceWriteTo(w); err != nil {
+			return err
+		}
+	}
 
 	if stmt.where.IsValid() {
 		w.WriteString(" WHERE ")
 		stmt.where.WriteTo(w)
 	}
 
+	stmt.returningWriteTo(w)
+
 	return nil
 }
 
+// targetCheck checks the target of the update or delete, i.e., the first of the
+// tables, which cannot be a sub statement.
+func (stmt *Stmt) targetCheck() error {
+	if len(stmt.tableFrom) <= 0 {
+		return ErrNoTableName
+	}
+	if _, ok := stmt.tableFrom[0].(*fromTable); !ok {
+		return ErrNotTableTarget
+	}
+	return nil
+}
+
+// multiTable checks whether the update or delete has the sources other than the target
+func (stmt *Stmt) multiTable() bool {
+	return len(stmt.tableFrom) > 1 || len(stmt.tableJoin) > 0
+}
+
+// sourceWriteTo writes the sources of "UPDATE ... FROM" and "DELETE ... USING",
+// i.e., the tables after the target and the joins. The joins need a source to
+// join with, as they cannot refer to the target.
+func (stmt *Stmt) sourceWriteTo(w *Writer) error {
+	if len(stmt.tableFrom) < 2 {
+		return ErrNoTableName
+	}
+
+	for i, from := range stmt.tableFrom[1:] {
+		if i > 0 {
+			w.WriteByte(',')
+		}
+		from.writeTo(w)
+	}
+	return stmt.joinWriteTo(w)
+}
+
+// sourceJoinWriteTo writes the sources of the multi-table update and delete of
+// MySQL, which joins the target with the other tables.
+func (stmt *Stmt) sourceJoinWriteTo(w *Writer) error {
+	for _, from := range stmt.tableFrom[1:] {
+		w.WriteString(" JOIN ")
+		from.writeTo(w)
+	}
+	return stmt.joinWriteTo(w)
+}
+
 func (stmt *Stmt) selectWriteTo(w *Writer) error {
 	if len(stmt.tableFrom) <= 0 {
 		return ErrNoTableName
@@ -214,12 +362,12 @@ func (stmt *Stmt) selectWriteTo(w *Writer) error {
 
 	w.WriteString("SELECT ")
 
-	if len(stmt.SelectCols) > 0 {
-		w.Join(stmt.SelectCols, ',')
-	} else {
-		w.WriteByte('*')
+	if err := stmt.distinctWriteTo(w); err != nil {
+		return err
 	}
 
+	stmt.selectColsWriteTo(w)
+
 	w.WriteString(" FROM ")
 
 	for i, from := range stmt.tableFrom {
@@ -229,14 +377,43 @@ func (stmt *Stmt) selectWriteTo(w *Writer) error {
 		}
 	}
 
-	if stmt.where.IsValid() {
+	if err := stmt.joinWriteTo(w); err != nil {
+		return err
+	}
+
+	where := stmt.where
+	if stmt.cursor != nil {
+		keyset, err := stmt.keysetCond(w.schema)
+		if err != nil {
+			return err
+		}
+		// the temporary conditions are released, while the ones of the
+		// statement are kept.
+		where = And(stmt.where, keyset)
+		defer func() {
+			if where != keyset {
+				where.Destroy()
+			}
+			keyset.Destroy()
+		}()
+	}
+
+	if where.IsValid() {
 		w.WriteString(" WHERE ")
-		stmt.where.WriteTo(w)
+		where.WriteTo(w)
+	} else if w.schema == db.SchSQLite && stmt.sqlType == InsertType && stmt.conflict.isValid() {
+		// SQLite requires WHERE in the select of the upsert, otherwise,
+		// ON CONFLICT is parsed as the constraint of the join.
+		w.WriteString(" WHERE true")
 	}
 
-	if stmt.GroupByStr.Len() > 0 {
+	if len(stmt.groupBy) > 0 {
 		w.WriteString(" GROUP BY ")
-		w.Write(stmt.GroupByStr.Bytes())
+		w.WriteIdent(stmt.groupBy[0])
+		for _, key := range stmt.groupBy[1:] {
+			w.WriteString(", ")
+			w.WriteIdent(key)
+		}
 	}
 
 	if stmt.having.IsValid() {
@@ -244,15 +421,68 @@ func (stmt *Stmt) selectWriteTo(w *Writer) error {
 		stmt.having.WriteTo(w)
 	}
 
+	stmt.windowWriteTo(w)
+
+	if err := stmt.orderLimitWriteTo(w); err != nil {
+		return err
+	}
+
+	return stmt.lockWriteTo(w)
+}
+
+// distinctWriteTo writes "DISTINCT " or "DISTINCT ON (cols) ". DISTINCT ON is
+// only supported by Postgres, and its cols must lead ORDER BY.
+func (stmt *Stmt) distinctWriteTo(w *Writer) error {
+	if !stmt.distinct {
+		return nil
+	}
+
+	if len(stmt.distinctOn) == 0 {
+		w.WriteString("DISTINCT ")
+		return nil
+	}
+
+	if w.schema == db.SchMYSQL || w.schema == db.SchSQLite {
+		return ErrNotSupportDialectFeature
+	}
+
+	// the leading items of ORDER BY are the cols in any order
+	if orders := stmt.orderItems(); len(orders) > 0 {
+		if len(orders) < len(stmt.distinctOn) {
+			return ErrDistinctOnOrder
+		}
+		for _, col := range stmt.distinctOn {
+			if !slices.ContainsFunc(orders[:len(stmt.distinctOn)], func(item orderItem) bool {
+				return item.col == col
+			}) {
+				return ErrDistinctOnOrder
+			}
+		}
+	}
+
+	w.WriteString("DISTINCT ON (")
+	w.Join(stmt.distinctOn, ',')
+	w.WriteString(") ")
+	return nil
+}
+
+func (stmt *Stmt) orderLimitWriteTo(w *Writer) error {
 	if stmt.OrderByStr.Len() > 0 {
 		w.WriteString(" ORDER BY ")
-		w.Write(stmt.OrderByStr.Bytes())
+		stmt.orderByWriteTo(w)
 	}
 
 	if stmt.LimitN < 0 || stmt.Offset < 0 {
 		return ErrInvalidLimitation
 	} else if stmt.LimitN > 0 {
 		w.WriteString(" LIMIT ")
+		// MySQL writes "LIMIT offset,count"
+		if stmt.Offset != 0 && w.schema == db.SchMYSQL {
+			w.WriteString(strconv.Itoa(stmt.Offset))
+			w.WriteByte(',')
+			w.WriteString(strconv.Itoa(stmt.LimitN))
+			return nil
+		}
 		w.WriteString(strconv.Itoa(stmt.LimitN))
 		if stmt.Offset != 0 {
 			w.WriteString(" OFFSET ")
@@ -262,3 +492,23 @@ func (stmt *Stmt) selectWriteTo(w *Writer) error {
 
 	return nil
 }
+
+// returningWriteTo writes RETURNING. It is skipped for the dialect without RETURNING,
+// and the returned rows are fetched by the executor instead.
+func (stmt *Stmt) returningWriteTo(w *Writer) {
+	if len(stmt.ReturningCols) > 0 && supportReturning(w.schema) {
+		w.WriteString(" RETURNING ")
+		w.JoinIdent(stmt.ReturningCols, ',')
+	}
+}
+
+func (stmt *Stmt) joinWriteTo(w *Writer) error {
+	for _, join := range stmt.tableJoin {
+		// MySQL does not support FULL JOIN
+		if join.joinType == joinFull && w.schema == db.SchMYSQL {
+			return ErrNotSupportJoinType
+		}
+		join.writeTo(w)
+	}
+	return nil
+}
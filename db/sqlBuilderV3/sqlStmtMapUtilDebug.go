@@ -48,9 +48,7 @@ func (stmt *Stmt) buildInsertColsByMap(data Map) {
 
 func catCondMap(ref *[]Cond, query Map, conds *condAnd) {
 	for _, k := range query.sortedKeys() {
-		cond := Expr(k, query[k])
-		cond.appendSql(" = ")
-		cond.appendSql(db.Para)
+		cond := colEq(k, query[k])
 		// self created cond is stored in the ref
 		*ref = append(*ref, cond)
 		*conds = append(*conds, cond)
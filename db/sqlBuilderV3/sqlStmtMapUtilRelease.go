@@ -44,9 +44,7 @@ func (stmt *Stmt) buildInsertColsByMap(data Map) {
 
 func catCondMap(ref *[]Cond, query Map, conds *condAnd) {
 	for k, v := range query {
-		cond := Expr(k, v)
-		cond.appendSql(" = ")
-		cond.appendSql(db.Para)
+		cond := colEq(k, v)
 		// self created cond is stored in the ref
 		*ref = append(*ref, cond)
 		*conds = append(*conds, cond)
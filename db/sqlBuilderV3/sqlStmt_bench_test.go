@@ -1,8 +1,10 @@
 package sqlBuilderV3_test
 
 import (
+	"sync/atomic"
 	"testing"
 
+	"github.com/secure-for-ai/secureai-microsvs/db"
 	"github.com/secure-for-ai/secureai-microsvs/db/sqlBuilderV3"
 	"github.com/stretchr/testify/assert"
 )
@@ -25,6 +27,9 @@ var stuStruct2 = student2{
 	ts.Unix(),
 }
 
+// benchArgs keeps the bound args alive, so that Bind is not optimized away
+var benchArgs atomic.Value
+
 var stuVal2 = sqlBuilderV3.Map{
 	"uid":         uid,
 	"username":    "Alice",
@@ -1178,3 +1183,60 @@ func BenchmarkSQLStmtGroupByHaving(b *testing.B) {
 		}
 	})
 }
+
+func BenchmarkSQLStmtSelectPG(b *testing.B) {
+	b.ReportAllocs()
+	b.ResetTimer()
+	b.StartTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		var stuInterface any = stuStruct
+
+		// the statement of the same shape is rebuilt for every request
+		for pb.Next() {
+			w := sqlBuilderV3.NewWriter()
+
+			stmt := sqlBuilderV3.Select(stuInterface).Where(sqlBuilderV3.Map{"uid": 100}).Limit(1)
+			stmt.Gen(w, db.SchPG)
+
+			stmt.Destroy()
+			w.Destroy()
+		}
+	})
+}
+
+func BenchmarkSQLStmtSelectCompiledAssert(b *testing.B) {
+	stmt := sqlBuilderV3.Select(stuStruct).Where(sqlBuilderV3.Map{"uid": sqlBuilderV3.Slot("uid")}).Limit(1)
+	compiled, err := stmt.Compile(db.SchPG)
+	stmt.Destroy()
+	assert.NoError(b, err)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	b.StartTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			args, err := compiled.Bind(100)
+			assert.NoError(b, err)
+			assert.EqualValues(b, "SELECT uid,username,nickname,email,age,enrolled,gpa,tokens,comp,create_time,update_time FROM student WHERE uid = $1 LIMIT 1", compiled.SQL())
+			assert.EqualValues(b, []any{100}, args)
+		}
+	})
+}
+
+func BenchmarkSQLStmtSelectCompiled(b *testing.B) {
+	stmt := sqlBuilderV3.Select(stuStruct).Where(sqlBuilderV3.Map{"uid": sqlBuilderV3.Slot("uid")}).Limit(1)
+	compiled, _ := stmt.Compile(db.SchPG)
+	stmt.Destroy()
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	b.StartTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		// only the args are bound for every request
+		var args []any
+		for pb.Next() {
+			args, _ = compiled.Bind(100)
+		}
+		benchArgs.Store(args)
+	})
+}
@@ -1,6 +1,11 @@
 package sqlBuilderV3_test
 
 import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"strings"
 	"testing"
 	"time"
 
@@ -752,3 +757,1264 @@ func TestSQLStmt_Nest(t *testing.T) {
 	sql, args, err = sqlBuilderV3.Select().From(selectStmt, "S1").From(selectStmt2, "S2").Gen(w)
 	evalJoin()
 }
+
+func TestSQLStmt_Join(t *testing.T) {
+	var sql string
+	var args []any
+	var err error
+
+	evalJoin := func() {
+		assert.NoError(t, err)
+		assert.EqualValues(t, "SELECT S.uid,G.score FROM student AS S LEFT JOIN grade AS G ON (G.uid = S.uid) AND (G.score > ?) WHERE S.uid = ?", sql)
+		assert.EqualValues(t, []any{60, uid}, args)
+	}
+	sql, args, err = sqlBuilderV3.Select().SelectColumns("S.uid", "G.score").From(&stuStruct, "S").
+		LeftJoin("grade", "G", "G.uid = S.uid").Where("S.uid = ??", uid).Gen(w)
+	assert.NoError(t, err)
+	assert.EqualValues(t, "SELECT S.uid,G.score FROM student AS S LEFT JOIN grade AS G ON G.uid = S.uid WHERE S.uid = ?", sql)
+	assert.EqualValues(t, []any{uid}, args)
+	sql, args, err = sqlBuilderV3.Select().SelectColumns("S.uid", "G.score").From(&stuStruct, "S").
+		LeftJoin("grade", "G", sqlBuilderV3.And(sqlBuilderV3.Expr("G.uid = S.uid"), sqlBuilderV3.Expr("G.score > ??", 60))).
+		Where("S.uid = ??", uid).Gen(w)
+	evalJoin()
+
+	evalTypes := func() {
+		assert.NoError(t, err)
+		assert.EqualValues(t, "SELECT * FROM student AS S "+
+			"JOIN grade AS G1 ON G1.uid = S.uid "+
+			"INNER JOIN grade AS G2 ON G2.uid = S.uid "+
+			"RIGHT JOIN grade AS G3 ON G3.uid = S.uid "+
+			"FULL JOIN grade AS G4 ON G4.uid = S.uid "+
+			"CROSS JOIN course", sql)
+		assert.EqualValues(t, []any{}, args)
+	}
+	sql, args, err = sqlBuilderV3.Select().From(&stuStruct, "S").
+		Join("grade", "G1", "G1.uid = S.uid").
+		InnerJoin("grade", "G2", "G2.uid = S.uid").
+		RightJoin("grade", "G3", "G3.uid = S.uid").
+		FullJoin("grade", "G4", "G4.uid = S.uid").
+		CrossJoin("course").Gen(w, db.SchPG)
+	evalTypes()
+
+	// the arguments of the sub statement and the ON condition are in the order of the SQL
+	evalSub := func() {
+		assert.NoError(t, err)
+		assert.EqualValues(t, "SELECT * FROM student AS S "+
+			"JOIN (SELECT uid,username,nickname,email,age,enrolled,gpa,tokens,comp,create_time,update_time FROM student WHERE uid = $1) AS S2 "+
+			"ON (S2.uid = S.uid) AND (S2.username = $2) WHERE S.uid = $3", sql)
+		assert.EqualValues(t, []any{uid, "Alice", uid}, args)
+	}
+	subStmt := sqlBuilderV3.Select(&stuStruct).Where(stuMapUid)
+	stmt := sqlBuilderV3.Select().From(&stuStruct, "S").
+		Join(subStmt, "S2", sqlBuilderV3.Expr("S2.uid = S.uid"), sqlBuilderV3.Expr("S2.username = ??", "Alice")).
+		Where("S.uid = ??", uid)
+	sql, args, err = stmt.Gen(w, db.SchPG)
+	evalSub()
+	stmt.Destroy()
+
+	// MySQL does not support FULL JOIN
+	_, _, err = sqlBuilderV3.Select().From(&stuStruct, "S").
+		FullJoin("grade", "G", "G.uid = S.uid").Gen(w, db.SchMYSQL)
+	assert.EqualError(t, err, sqlBuilderV3.ErrNotSupportJoinType.Error())
+	sql, _, err = sqlBuilderV3.Select().From(&stuStruct, "S").
+		LeftJoin("grade", "G", "G.uid = S.uid").Gen(w, db.SchMYSQL)
+	assert.NoError(t, err)
+	assert.EqualValues(t, "SELECT * FROM `student` AS S LEFT JOIN `grade` AS G ON G.uid = S.uid", sql)
+}
+
+func TestSQLStmt_Returning(t *testing.T) {
+	var sql string
+	var args []any
+	var err error
+
+	sql, args, err = sqlBuilderV3.Insert(&stuStruct).Returning("uid", "create_time").Gen(w, db.SchPG)
+	assert.NoError(t, err)
+	assert.EqualValues(t, "INSERT INTO student (uid,username,nickname,email,age,enrolled,gpa,tokens,comp,create_time,update_time) "+
+		"VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11) RETURNING uid,create_time", sql)
+	assert.EqualValues(t, stuStructArr, args)
+
+	sql, _, err = sqlBuilderV3.InsertBulk(stuList).Returning(sqlBuilderV3.Columns{"uid"}).Gen(w, db.SchPG)
+	assert.NoError(t, err)
+	assert.EqualValues(t, "INSERT INTO student (uid,username,nickname,email,age,enrolled,gpa,tokens,comp,create_time,update_time) "+
+		"VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11) RETURNING uid", sql)
+
+	sql, args, err = sqlBuilderV3.Insert(&stuStruct).Select(&stuStruct).Where(stuMapUid).Returning("uid").Gen(w)
+	assert.NoError(t, err)
+	assert.EqualValues(t, "INSERT INTO student (uid,username,nickname,email,age,enrolled,gpa,tokens,comp,create_time,update_time) "+
+		"SELECT uid,username,nickname,email,age,enrolled,gpa,tokens,comp,create_time,update_time FROM student WHERE uid = ? RETURNING uid", sql)
+	assert.EqualValues(t, []any{uid}, args)
+
+	sql, args, err = sqlBuilderV3.Update("student").Set("username", "Bob").Where(stuMapUid).Returning(&stuStruct).Gen(w)
+	assert.NoError(t, err)
+	assert.EqualValues(t, "UPDATE student SET username = ? WHERE uid = ? "+
+		"RETURNING uid,username,nickname,email,age,enrolled,gpa,tokens,comp,create_time,update_time", sql)
+	assert.EqualValues(t, []any{"Bob", uid}, args)
+
+	sql, args, err = sqlBuilderV3.Delete("student", stuMapUid).Returning("uid", "username").Gen(w)
+	assert.NoError(t, err)
+	assert.EqualValues(t, "DELETE FROM student WHERE uid = ? RETURNING uid,username", sql)
+	assert.EqualValues(t, []any{uid}, args)
+}
+
+func TestSQLStmt_Upsert(t *testing.T) {
+	var sql string
+	var args []any
+	var err error
+	const insertSQL = "INSERT INTO student (uid,username,nickname,email,age,enrolled,gpa,tokens,comp,create_time,update_time) "
+
+	sql, args, err = sqlBuilderV3.Insert(&stuStruct).OnConflict("username").DoNothing().Gen(w, db.SchPG)
+	assert.NoError(t, err)
+	assert.EqualValues(t, insertSQL+"VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11) ON CONFLICT (username) DO NOTHING", sql)
+	assert.EqualValues(t, stuStructArr, args)
+	sql, _, err = sqlBuilderV3.Insert(&stuStruct).OnConflict("username").Gen(w, db.SchPG)
+	assert.NoError(t, err)
+	assert.EqualValues(t, insertSQL+"VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11) ON CONFLICT (username) DO NOTHING", sql)
+	sql, _, err = sqlBuilderV3.Insert(&stuStruct).DoNothing().Gen(w, db.SchPG)
+	assert.NoError(t, err)
+	assert.EqualValues(t, insertSQL+"VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11) ON CONFLICT DO NOTHING", sql)
+
+	evalUpdate := func() {
+		assert.NoError(t, err)
+		assert.EqualValues(t, insertSQL+"VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11) "+
+			"ON CONFLICT ON CONSTRAINT student_pk_uid DO UPDATE SET nickname = EXCLUDED.nickname,age = student.age + $12 "+
+			"WHERE student.enrolled = $13 RETURNING uid", sql)
+		assert.EqualValues(t, append(stuStructArr, 1, true), args)
+	}
+	sql, args, err = sqlBuilderV3.Insert(&stuStruct).OnConstraint("student_pk_uid").
+		DoUpdateSet("nickname", sqlBuilderV3.Excluded("nickname")).
+		DoUpdateSet("age", "student.age + ??", 1).
+		DoUpdateWhere("student.enrolled = ??", true).Returning("uid").Gen(w, db.SchPG)
+	evalUpdate()
+	sql, args, err = sqlBuilderV3.Insert(&stuStruct).OnConstraint("student_pk_uid").
+		DoUpdateExcluded("nickname").
+		DoUpdateSet(sqlBuilderV3.Map{"age": sqlBuilderV3.Expr("student.age + ??", 1)}).
+		DoUpdateWhere(sqlBuilderV3.Map{"student.enrolled": true}).Returning("uid").Gen(w, db.SchPG)
+	evalUpdate()
+
+	// struct values
+	sql, args, err = sqlBuilderV3.Insert().IntoTable("student").IntoColumns("uid", "username").Values([]any{uid, "Alice"}).
+		OnConflict("uid").DoUpdateSet(&stuStruct2).Gen(w, db.SchPG)
+	assert.NoError(t, err)
+	assert.EqualValues(t, "INSERT INTO student (uid,username) VALUES ($1,$2) ON CONFLICT (uid) DO UPDATE SET "+
+		"uid = $3,username = $4,nickname = $5,email = $6,create_time = $7,update_time = $8", sql)
+	assert.EqualValues(t, []any{uid, "Alice", uid, "Alice", "Ali", "ali@gmail.com", ts.Unix(), ts.Unix()}, args)
+
+	// the args of the upsert are appended to every row of the bulk insertion
+	sql, _, err = sqlBuilderV3.InsertBulk(stuList).OnConflict("uid").DoUpdateSet("age", "student.age + ??", 1).Gen(w, db.SchPG)
+	assert.NoError(t, err)
+	assert.EqualValues(t, insertSQL+"VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11) ON CONFLICT (uid) DO UPDATE SET age = student.age + $12", sql)
+	assert.EqualValues(t, []*[]any{fastArgs(append(stuStructArr, 1)), fastArgs(append(stuStructArr, 1))}, w.BulkArgs())
+
+	// MySQL
+	const mysqlInsertSQL = "INSERT INTO `student` (`uid`,`username`,`nickname`,`email`,`age`,`enrolled`,`gpa`,`tokens`,`comp`,`create_time`,`update_time`) "
+	sql, args, err = sqlBuilderV3.Insert(&stuStruct).OnConflict("uid").
+		DoUpdateSet("nickname", sqlBuilderV3.Excluded("nickname")).
+		DoUpdateSet("age", "age + ??", 1).
+		DoUpdateSet("gpa", sqlBuilderV3.Expr("GREATEST(gpa, EXCLUDED.gpa)")).Gen(w, db.SchMYSQL)
+	assert.NoError(t, err)
+	assert.EqualValues(t, mysqlInsertSQL+"VALUES (?,?,?,?,?,?,?,?,?,?,?) "+
+		"ON DUPLICATE KEY UPDATE `nickname` = VALUES(`nickname`),`age` = age + ?,`gpa` = GREATEST(gpa, VALUES(`gpa`))", sql)
+	assert.EqualValues(t, append(stuStructArr, 1), args)
+	sql, _, err = sqlBuilderV3.Insert(&stuStruct).OnConflict("username").DoNothing().Gen(w, db.SchMYSQL)
+	assert.NoError(t, err)
+	assert.EqualValues(t, mysqlInsertSQL+"VALUES (?,?,?,?,?,?,?,?,?,?,?) ON DUPLICATE KEY UPDATE `username` = `username`", sql)
+	sql, _, err = sqlBuilderV3.Insert(&stuStruct).DoNothing().Gen(w, db.SchMYSQL)
+	assert.NoError(t, err)
+	assert.EqualValues(t, mysqlInsertSQL+"VALUES (?,?,?,?,?,?,?,?,?,?,?) ON DUPLICATE KEY UPDATE `uid` = `uid`", sql)
+
+	// errors
+	_, _, err = sqlBuilderV3.Insert(&stuStruct).DoUpdateExcluded("nickname").Gen(w, db.SchPG)
+	assert.EqualError(t, err, sqlBuilderV3.ErrNoConflictTarget.Error())
+	_, _, err = sqlBuilderV3.Insert(&stuStruct).OnConflict("uid").DoUpdateExcluded().Gen(w, db.SchPG)
+	assert.EqualError(t, err, sqlBuilderV3.ErrNoColumnToUpdate.Error())
+	_, _, err = sqlBuilderV3.Insert(&stuStruct).OnConflict("uid").DoUpdateExcluded("nickname").
+		DoUpdateWhere("enrolled = ??", true).Gen(w, db.SchMYSQL)
+	assert.EqualError(t, err, sqlBuilderV3.ErrNotSupportDialectFeature.Error())
+}
+
+func fastArgs(args []any) *[]any {
+	return &args
+}
+
+func TestSQLStmt_With(t *testing.T) {
+	var sql string
+	var args []any
+	var err error
+
+	// placeholders are numbered across the WITH and the main statement
+	stmt := sqlBuilderV3.Select().From("top_stu").Where("age > ??", 18).
+		With("top_stu", sqlBuilderV3.Select("student").Where("gpa > ??", 3.5), "uid", "age")
+	sql, args, err = stmt.Gen(w, db.SchPG)
+	assert.NoError(t, err)
+	assert.EqualValues(t, "WITH top_stu (uid,age) AS (SELECT * FROM student WHERE gpa > $1) SELECT * FROM top_stu WHERE age > $2", sql)
+	assert.EqualValues(t, []any{3.5, 18}, args)
+	stmt.Destroy()
+
+	evalRecursive := func() {
+		assert.NoError(t, err)
+		assert.EqualValues(t, "WITH RECURSIVE org (id,parent_id) AS ("+
+			"SELECT id,parent_id FROM org_unit WHERE id = $1 UNION ALL "+
+			"SELECT o.id,o.parent_id FROM org_unit AS o JOIN org AS p ON o.parent_id = p.id WHERE o.depth < $2), "+
+			"admin AS (SELECT uid FROM org_admin WHERE role = $3) "+
+			"SELECT * FROM org WHERE id IN (SELECT uid FROM admin) LIMIT 10", sql)
+		assert.EqualValues(t, []any{1, 5, "owner"}, args)
+	}
+	anchor := sqlBuilderV3.Select().SelectColumns("id", "parent_id").From("org_unit").Where("id = ??", 1)
+	recursive := sqlBuilderV3.Select().SelectColumns("o.id", "o.parent_id").From("org_unit", "o").
+		Join("org", "p", "o.parent_id = p.id").Where("o.depth < ??", 5)
+	stmt = sqlBuilderV3.Select("org").Where("id IN (SELECT uid FROM admin)").Limit(10).
+		WithRecursive("org", []string{"id", "parent_id"}, anchor, recursive).
+		With("admin", sqlBuilderV3.Select().SelectColumns("uid").From("org_admin").Where("role = ??", "owner"))
+	sql, args, err = stmt.Gen(w, db.SchPG)
+	evalRecursive()
+	stmt.Destroy()
+
+	// insert, update and delete
+	stmt = sqlBuilderV3.Insert().IntoTable("student_archive").Values(sqlBuilderV3.Select("old_stu")).
+		With("old_stu", sqlBuilderV3.Select("student").Where("create_time < ??", ts.Unix()))
+	sql, args, err = stmt.Gen(w, db.SchPG)
+	assert.NoError(t, err)
+	assert.EqualValues(t, "WITH old_stu AS (SELECT * FROM student WHERE create_time < $1) "+
+		"INSERT INTO student_archive (SELECT * FROM old_stu)", sql)
+	assert.EqualValues(t, []any{ts.Unix()}, args)
+	stmt.Destroy()
+
+	stmt = sqlBuilderV3.Update("student").Set("enrolled", false).Where("uid IN (SELECT uid FROM old_stu)").
+		With("old_stu", sqlBuilderV3.Select().SelectColumns("uid").From("student").Where("create_time < ??", ts.Unix()))
+	sql, args, err = stmt.Gen(w, db.SchPG)
+	assert.NoError(t, err)
+	assert.EqualValues(t, "WITH old_stu AS (SELECT uid FROM student WHERE create_time < $1) "+
+		"UPDATE student SET enrolled = $2 WHERE uid IN (SELECT uid FROM old_stu)", sql)
+	assert.EqualValues(t, []any{ts.Unix(), false}, args)
+	stmt.Destroy()
+
+	stmt = sqlBuilderV3.Delete("student", "uid IN (SELECT uid FROM old_stu)").
+		With("old_stu", sqlBuilderV3.Select().SelectColumns("uid").From("student").Where("create_time < ??", ts.Unix()))
+	sql, args, err = stmt.Gen(w)
+	assert.NoError(t, err)
+	assert.EqualValues(t, "WITH old_stu AS (SELECT uid FROM student WHERE create_time < ?) "+
+		"DELETE FROM student WHERE uid IN (SELECT uid FROM old_stu)", sql)
+	assert.EqualValues(t, []any{ts.Unix()}, args)
+	stmt.Destroy()
+
+	// every row of the bulk insertion carries the args of WITH
+	stmt = sqlBuilderV3.Insert().IntoTable("student").IntoColumns("uid", "age").
+		Values([]any{uid, sqlBuilderV3.Expr("(SELECT MAX(age) FROM s) + ??", 1)}, []any{uid + 1, 20}).
+		With("s", sqlBuilderV3.Select("student").Where("gpa > ??", 3.5))
+	sql, _, err = stmt.Gen(w, db.SchPG)
+	assert.NoError(t, err)
+	assert.EqualValues(t, "WITH s AS (SELECT * FROM student WHERE gpa > $1) "+
+		"INSERT INTO student (uid,age) VALUES ($2,(SELECT MAX(age) FROM s) + $3)", sql)
+	assert.EqualValues(t, []*[]any{fastArgs([]any{3.5, uid, 1}), fastArgs([]any{3.5, uid + 1, 20})}, w.BulkArgs())
+	stmt.Destroy()
+}
+
+func TestSQLStmt_Compound(t *testing.T) {
+	var sql string
+	var args []any
+	var err error
+
+	evalUnion := func() {
+		assert.NoError(t, err)
+		assert.EqualValues(t, "SELECT uid FROM student WHERE age > $1 UNION SELECT uid FROM teacher WHERE age > $2 ORDER BY uid DESC LIMIT 10", sql)
+		assert.EqualValues(t, []any{18, 30}, args)
+	}
+	stmt := sqlBuilderV3.Union(
+		sqlBuilderV3.Select().SelectColumns("uid").From("student").Where("age > ??", 18),
+		sqlBuilderV3.Select().SelectColumns("uid").From("teacher").Where("age > ??", 30),
+	).Desc("uid").Limit(10)
+	sql, args, err = stmt.Gen(w, db.SchPG)
+	evalUnion()
+	stmt.Destroy()
+	stmt = sqlBuilderV3.Select().SelectColumns("uid").From("student").Where("age > ??", 18).
+		Union(sqlBuilderV3.Select().SelectColumns("uid").From("teacher").Where("age > ??", 30)).
+		Desc("uid").Limit(10)
+	sql, args, err = stmt.Gen(w, db.SchPG)
+	evalUnion()
+	stmt.Destroy()
+
+	// different set operations are applied from left to right
+	stmt = sqlBuilderV3.Select("a").UnionAll(sqlBuilderV3.Select("b"), sqlBuilderV3.Select("c")).
+		Intersect(sqlBuilderV3.Select("d").Limit(1)).
+		Except(sqlBuilderV3.Select("e"))
+	sql, args, err = stmt.Gen(w)
+	assert.NoError(t, err)
+	assert.EqualValues(t, "((SELECT * FROM a UNION ALL SELECT * FROM b UNION ALL SELECT * FROM c) "+
+		"INTERSECT (SELECT * FROM d LIMIT 1)) EXCEPT SELECT * FROM e", sql)
+	assert.EqualValues(t, []any{}, args)
+	stmt.Destroy()
+
+	// the compound statement with ORDER BY and LIMIT is wrapped
+	stmt = sqlBuilderV3.Intersect(sqlBuilderV3.Select("a"), sqlBuilderV3.Select("b")).Limit(5).
+		Intersect(sqlBuilderV3.Select("c"))
+	sql, _, err = stmt.Gen(w)
+	assert.NoError(t, err)
+	assert.EqualValues(t, "(SELECT * FROM a INTERSECT SELECT * FROM b LIMIT 5) INTERSECT SELECT * FROM c", sql)
+
+	// SQLite rejects the parenthesized member
+	sql, _, err = stmt.Gen(w, db.SchSQLite)
+	assert.NoError(t, err)
+	assert.EqualValues(t, "SELECT * FROM (SELECT * FROM a INTERSECT SELECT * FROM b LIMIT 5) INTERSECT SELECT * FROM c", sql)
+	stmt.Destroy()
+
+	// compound statement as a subquery
+	stmt = sqlBuilderV3.Select().SelectColumns("COUNT(*)").From(
+		sqlBuilderV3.Except(
+			sqlBuilderV3.Select().SelectColumns("uid").From("student"),
+			sqlBuilderV3.Select().SelectColumns("uid").From("graduate").Where("year = ??", 2020),
+		), "S").Where("S.uid > ??", uid)
+	sql, args, err = stmt.Gen(w, db.SchPG)
+	assert.NoError(t, err)
+	assert.EqualValues(t, "SELECT COUNT(*) FROM (SELECT uid FROM student EXCEPT SELECT uid FROM graduate WHERE year = $1) AS S WHERE S.uid > $2", sql)
+	assert.EqualValues(t, []any{2020, uid}, args)
+	stmt.Destroy()
+
+	_, _, err = sqlBuilderV3.Union().Gen(w)
+	assert.EqualError(t, err, sqlBuilderV3.ErrNoTableName.Error())
+}
+
+func TestSQLStmt_SubQuery(t *testing.T) {
+	var sql string
+	var args []any
+	var err error
+
+	stmt := sqlBuilderV3.Select().SelectColumns("uid", "name").From("student").
+		Where(sqlBuilderV3.Gt("age", 18)).
+		And(sqlBuilderV3.InSub("uid", sqlBuilderV3.Select().SelectColumns("uid").From("enroll").Where("course = ??", "math"))).
+		And(sqlBuilderV3.Eq("grade", 3))
+	sql, args, err = stmt.Gen(w, db.SchPG)
+	assert.NoError(t, err)
+	assert.EqualValues(t, "SELECT uid,name FROM student WHERE age > $1 AND uid IN (SELECT uid FROM enroll WHERE course = $2) AND grade = $3", sql)
+	assert.EqualValues(t, []any{18, "math", 3}, args)
+	stmt.Destroy()
+
+	// correlated subquery nested in And, Or and Not
+	stmt = sqlBuilderV3.Select().SelectColumns("uid").From("student", "S").
+		Where(sqlBuilderV3.Or(
+			sqlBuilderV3.Exists(sqlBuilderV3.Select().SelectColumns("1").From("enroll", "E").
+				Where("E.uid = S.uid AND E.score > ??", 90)),
+			sqlBuilderV3.Not(sqlBuilderV3.NotExists(sqlBuilderV3.Select().SelectColumns("1").From("absent", "A").
+				Where("A.uid = S.uid"))),
+		)).
+		Having(sqlBuilderV3.NotInSub("S.uid", sqlBuilderV3.Select().SelectColumns("uid").From("banned")))
+	sql, args, err = stmt.Gen(w)
+	assert.NoError(t, err)
+	assert.EqualValues(t, "SELECT uid FROM student AS S WHERE EXISTS (SELECT 1 FROM enroll AS E WHERE E.uid = S.uid AND E.score > ?) "+
+		"OR NOT (NOT EXISTS (SELECT 1 FROM absent AS A WHERE A.uid = S.uid)) "+
+		"HAVING S.uid NOT IN (SELECT uid FROM banned)", sql)
+	assert.EqualValues(t, []any{90}, args)
+	stmt.Destroy()
+
+	// subquery in the condition of update and delete
+	stmt = sqlBuilderV3.Update("student").Set(sqlBuilderV3.Map{"grade": 4}).
+		Where(sqlBuilderV3.InSub("uid", sqlBuilderV3.Select().SelectColumns("uid").From("enroll").Where("score >= ??", 60)))
+	sql, args, err = stmt.Gen(w, db.SchPG)
+	assert.NoError(t, err)
+	assert.EqualValues(t, "UPDATE student SET grade = $1 WHERE uid IN (SELECT uid FROM enroll WHERE score >= $2)", sql)
+	assert.EqualValues(t, []any{4, 60}, args)
+	stmt.Destroy()
+
+	stmt = sqlBuilderV3.Delete().From("student").
+		Where(sqlBuilderV3.NotExists(sqlBuilderV3.Select().SelectColumns("1").From("enroll").Where("enroll.uid = student.uid")))
+	sql, args, err = stmt.Gen(w, db.SchPG)
+	assert.NoError(t, err)
+	assert.EqualValues(t, "DELETE FROM student WHERE NOT EXISTS (SELECT 1 FROM enroll WHERE enroll.uid = student.uid)", sql)
+	assert.EqualValues(t, []any{}, args)
+	stmt.Destroy()
+
+	assert.EqualValues(t, sqlBuilderV3.CondEmpty, sqlBuilderV3.Exists(nil))
+
+	// the error of the sub statement is returned instead of the broken SQL
+	stmt = sqlBuilderV3.Select().From(sqlBuilderV3.Select().From("enroll").Limit(-1), "S")
+	sql, _, err = stmt.Gen(w, db.SchPG)
+	assert.ErrorIs(t, err, sqlBuilderV3.ErrInvalidLimitation)
+	assert.EqualValues(t, "", sql)
+	stmt.Destroy()
+
+	stmt = sqlBuilderV3.Select().From("student").
+		Where(sqlBuilderV3.InSub("uid", sqlBuilderV3.Select().From("enroll").Strict().Desc("uid; --")))
+	sql, _, err = stmt.Gen(w, db.SchPG)
+	assert.ErrorIs(t, err, sqlBuilderV3.ErrInvalidIdentifier)
+	assert.EqualValues(t, "", sql)
+	stmt.Destroy()
+}
+
+func TestSQLStmt_MySQL(t *testing.T) {
+	var sql string
+	var args []any
+	var err error
+
+	// multiple rows are inserted in a single statement
+	sql, args, err = sqlBuilderV3.InsertBulk(stuList).OnConflict().DoUpdateExcluded("nickname").Gen(w, db.SchMYSQL)
+	assert.NoError(t, err)
+	assert.EqualValues(t, "INSERT INTO `student` (`uid`,`username`,`nickname`,`email`,`age`,`enrolled`,`gpa`,`tokens`,`comp`,`create_time`,`update_time`) "+
+		"VALUES (?,?,?,?,?,?,?,?,?,?,?),(?,?,?,?,?,?,?,?,?,?,?) ON DUPLICATE KEY UPDATE `nickname` = VALUES(`nickname`)", sql)
+	assert.EqualValues(t, append(append([]any{}, stuStructArr...), stuStructArr...), args)
+	assert.Len(t, w.BulkArgs(), 0)
+
+	// identifiers are quoted, and LIMIT is written as "LIMIT offset,count"
+	sql, args, err = sqlBuilderV3.Select().SelectColumns("uid", "COUNT(*)").From("order", "O").
+		Join("app.student", "S", "S.uid = O.uid").Where("S.age > ??", 18).
+		GroupBy("uid").Limit(10, 20).Gen(w, db.SchMYSQL)
+	assert.NoError(t, err)
+	assert.EqualValues(t, "SELECT `uid`,COUNT(*) FROM `order` AS O JOIN `app`.`student` AS S ON S.uid = O.uid "+
+		"WHERE S.age > ? GROUP BY `uid` LIMIT 20,10", sql)
+	assert.EqualValues(t, []any{18}, args)
+	sql, _, err = sqlBuilderV3.Select("student").Limit(10, 20).Gen(w, db.SchPG)
+	assert.NoError(t, err)
+	assert.EqualValues(t, "SELECT * FROM student LIMIT 10 OFFSET 20", sql)
+
+	// RETURNING is emulated by ExecMySQL
+	sql, args, err = sqlBuilderV3.Update("student").Set(stuMapUsername).Where(stuMapUid).
+		Returning("uid", "username").Gen(w, db.SchMYSQL)
+	assert.NoError(t, err)
+	assert.EqualValues(t, "UPDATE `student` SET `username` = ? WHERE `uid` = ?", sql)
+	assert.EqualValues(t, []any{"Alice", uid}, args)
+
+	// the columns are quoted as well, so that the reserved words can be used as names
+	sql, args, err = sqlBuilderV3.Update("item").Set(sqlBuilderV3.Map{"key": "a"}).Incr("order").
+		Where(sqlBuilderV3.Map{"group": 1}).And(sqlBuilderV3.Gt("rank", 2)).Gen(w, db.SchMYSQL)
+	assert.NoError(t, err)
+	assert.EqualValues(t, "UPDATE `item` SET `key` = ?,`order` = `order` + ? WHERE (`group` = ?) AND `rank` > ?", sql)
+	assert.EqualValues(t, []any{"a", 1, 1, 2}, args)
+	sql, _, err = sqlBuilderV3.Select().SelectColumns("group", "COUNT(*) AS n").From("item").
+		GroupBy("group").OrderBy("group desc").Gen(w, db.SchMYSQL)
+	assert.NoError(t, err)
+	assert.EqualValues(t, "SELECT `group`,COUNT(*) AS n FROM `item` GROUP BY `group` ORDER BY `group` DESC", sql)
+	sql, _, err = sqlBuilderV3.Select().SelectColumns("group", "COUNT(*) AS n").From("item").
+		GroupBy("group").OrderBy("group desc").Gen(w, db.SchPG)
+	assert.NoError(t, err)
+	assert.EqualValues(t, "SELECT group,COUNT(*) AS n FROM item GROUP BY group ORDER BY group desc", sql)
+
+	// the exported fields keep the clauses as given
+	stmt := sqlBuilderV3.Update("item").Set(sqlBuilderV3.Map{"key": "a"}).Incr("order").GroupBy("a", "b").GroupBy("c")
+	assert.EqualValues(t, "key = ??,order = order + ??", stmt.SetCols.String())
+	assert.EqualValues(t, "a, b, c", stmt.GroupByStr.String())
+	stmt.Destroy()
+}
+
+func TestSQLStmt_SQLite(t *testing.T) {
+	var sql string
+	var args []any
+	var err error
+
+	// multiple rows are inserted in a single statement, and RETURNING is kept
+	sql, args, err = sqlBuilderV3.InsertBulk(stuList).OnConflict("uid").DoUpdateExcluded("nickname").
+		Returning("uid").Gen(w, db.SchSQLite)
+	assert.NoError(t, err)
+	assert.EqualValues(t, "INSERT INTO student (uid,username,nickname,email,age,enrolled,gpa,tokens,comp,create_time,update_time) "+
+		"VALUES (?,?,?,?,?,?,?,?,?,?,?),(?,?,?,?,?,?,?,?,?,?,?) ON CONFLICT (uid) DO UPDATE SET nickname = EXCLUDED.nickname RETURNING uid", sql)
+	assert.EqualValues(t, append(append([]any{}, stuStructArr...), stuStructArr...), args)
+
+	// the select of the upsert always has WHERE
+	sql, args, err = sqlBuilderV3.Insert().IntoTable("student").Select(stuStruct).OnConflict().DoNothing().Gen(w, db.SchSQLite)
+	assert.NoError(t, err)
+	assert.EqualValues(t, "INSERT INTO student SELECT uid,username,nickname,email,age,enrolled,gpa,tokens,comp,create_time,update_time "+
+		"FROM student WHERE true ON CONFLICT DO NOTHING", sql)
+	assert.EqualValues(t, []any{}, args)
+	sql, args, err = sqlBuilderV3.Insert().IntoTable("student").Select(stuStruct).Where(stuMapUid).
+		OnConflict("uid").DoNothing().Gen(w, db.SchSQLite)
+	assert.NoError(t, err)
+	assert.EqualValues(t, "INSERT INTO student SELECT uid,username,nickname,email,age,enrolled,gpa,tokens,comp,create_time,update_time "+
+		"FROM student WHERE uid = ? ON CONFLICT (uid) DO NOTHING", sql)
+	assert.EqualValues(t, []any{uid}, args)
+
+	sql, args, err = sqlBuilderV3.Delete().From("student").Where(sqlBuilderV3.In("uid", []int64{1, 2})).
+		Returning("uid").Gen(w, db.SchSQLite)
+	assert.NoError(t, err)
+	assert.EqualValues(t, "DELETE FROM student WHERE uid IN (?,?) RETURNING uid", sql)
+	assert.EqualValues(t, []any{int64(1), int64(2)}, args)
+
+	_, _, err = sqlBuilderV3.Insert(&stuStruct).OnConstraint("student_pkey").DoNothing().Gen(w, db.SchSQLite)
+	assert.EqualError(t, err, sqlBuilderV3.ErrNotSupportDialectFeature.Error())
+}
+
+func TestSQLStmt_Strict(t *testing.T) {
+	var sql string
+	var args []any
+	var err error
+
+	// safe identifiers are accepted
+	sql, args, err = sqlBuilderV3.Select().Strict().SelectColumns("S.uid", "name").From("student", "S").
+		Where(sqlBuilderV3.Map{"S.age": 18}).GroupBy("S.uid", "name").OrderBy("name desc nulls last").
+		Asc("S.uid").Gen(w, db.SchPG)
+	assert.NoError(t, err)
+	assert.EqualValues(t, "SELECT S.uid,name FROM student AS S WHERE S.age = $1 GROUP BY S.uid, name ORDER BY name desc nulls last, S.uid ASC", sql)
+	assert.EqualValues(t, []any{18}, args)
+
+	// the sort column from the user input is rejected
+	evalInvalid := func(ident string) {
+		var identErr *sqlBuilderV3.IdentError
+		assert.ErrorIs(t, err, sqlBuilderV3.ErrInvalidIdentifier)
+		assert.ErrorAs(t, err, &identErr)
+		assert.EqualValues(t, ident, identErr.Ident)
+		assert.False(t, identErr.NotAllowed)
+		assert.EqualValues(t, "", sql)
+	}
+	sql, _, err = sqlBuilderV3.Select("student").Strict().Desc("uid; DROP TABLE student").Gen(w, db.SchPG)
+	evalInvalid("uid; DROP TABLE student")
+	sql, _, err = sqlBuilderV3.Select("student").Strict().OrderBy("uid DESC, (SELECT 1)").Gen(w, db.SchPG)
+	evalInvalid("(SELECT 1)")
+	sql, _, err = sqlBuilderV3.SQL().Strict().Select("student s").Gen(w, db.SchPG)
+	evalInvalid("student s")
+	sql, _, err = sqlBuilderV3.SQL().Strict().Update("student").Set(sqlBuilderV3.Map{"age = 0 --": 1}).Gen(w, db.SchPG)
+	evalInvalid("age = 0 --")
+	sql, _, err = sqlBuilderV3.SQL().Strict().Insert().IntoTable("student").IntoColumns("1uid").Values([]any{1}).Gen(w, db.SchPG)
+	evalInvalid("1uid")
+	// the identifiers added before Strict are validated as well
+	sql, _, err = sqlBuilderV3.Select("student").Desc("uid; DROP TABLE student").Strict().Gen(w, db.SchPG)
+	evalInvalid("uid; DROP TABLE student")
+	sql, _, err = sqlBuilderV3.Select("student").GroupBy("COUNT(*)").Strict().Gen(w, db.SchPG)
+	evalInvalid("COUNT(*)")
+	sql, _, err = sqlBuilderV3.Update("student").Set(sqlBuilderV3.Map{"age = 0 --": 1}).Strict().Gen(w, db.SchPG)
+	evalInvalid("age = 0 --")
+	sql, _, err = sqlBuilderV3.Update("student").Incr("age = 0, uid").Strict().Gen(w, db.SchPG)
+	evalInvalid("age = 0, uid")
+	sql, _, err = sqlBuilderV3.Select("student").Where(sqlBuilderV3.Map{"1=1 OR uid": 1}).Strict().Gen(w, db.SchPG)
+	evalInvalid("1=1 OR uid")
+	sql, _, err = sqlBuilderV3.Insert(&stuStruct).OnConflict("uid").DoUpdateExcluded("age; --").Strict().Gen(w, db.SchPG)
+	evalInvalid("age; --")
+	// the columns of the typed conditions
+	sql, _, err = sqlBuilderV3.Select("student").Strict().Where(sqlBuilderV3.Eq("uid; drop", 1)).Gen(w, db.SchPG)
+	evalInvalid("uid; drop")
+	sql, _, err = sqlBuilderV3.Select("student").Where(sqlBuilderV3.Or(sqlBuilderV3.Eq("age", 1),
+		sqlBuilderV3.Not(sqlBuilderV3.In("grade)--", 1, 2)))).Strict().Gen(w, db.SchPG)
+	evalInvalid("grade)--")
+	sql, _, err = sqlBuilderV3.Select("student").Strict().Where(sqlBuilderV3.InSub("uid) OR (1=1",
+		sqlBuilderV3.Select().SelectColumns("uid").From("teacher"))).Gen(w, db.SchPG)
+	evalInvalid("uid) OR (1=1")
+	// the error is kept by the nested statement
+	sql, _, err = sqlBuilderV3.Union(sqlBuilderV3.Select("student"),
+		sqlBuilderV3.Select("teacher").Strict().GroupBy("COUNT(*)")).Gen(w, db.SchPG)
+	evalInvalid("COUNT(*)")
+
+	// the column allowlist
+	stmt := sqlBuilderV3.Select().AllowColumns(&stuStruct).SelectColumns("*").From("student", "S").Desc("S.age")
+	sql, _, err = stmt.Gen(w, db.SchPG)
+	assert.NoError(t, err)
+	assert.EqualValues(t, "SELECT * FROM student AS S ORDER BY S.age DESC", sql)
+	stmt.Asc("password")
+	sql, _, err = stmt.Gen(w, db.SchPG)
+	assert.ErrorIs(t, err, sqlBuilderV3.ErrInvalidIdentifier)
+	assert.EqualError(t, err, "Column not allowed: password")
+	stmt.Destroy()
+
+	// the strict mode is reset along with the statement
+	stmt = sqlBuilderV3.Select("student").Strict().Desc("age + 1")
+	stmt.Reset()
+	sql, _, err = stmt.Select("student").Desc("age + 1").Gen(w, db.SchPG)
+	assert.NoError(t, err)
+	assert.EqualValues(t, "SELECT * FROM student ORDER BY age + 1 DESC", sql)
+	stmt.Destroy()
+}
+
+func TestQuoteIdent(t *testing.T) {
+	assert.EqualValues(t, `"S"."uid"`, sqlBuilderV3.QuoteIdent("S.uid", db.SchPG))
+	assert.EqualValues(t, `"S".*`, sqlBuilderV3.QuoteIdent("S.*", db.SchSQLite))
+	assert.EqualValues(t, `"a""b"`, sqlBuilderV3.QuoteIdent(`a"b`, db.SchPG))
+	assert.EqualValues(t, "`order`", sqlBuilderV3.QuoteIdent("order", db.SchMYSQL))
+	assert.EqualValues(t, "`a``b`", sqlBuilderV3.QuoteIdent("a`b", db.SchMYSQL))
+}
+
+// recordQuerier records the executed statements instead of running them
+type recordQuerier struct {
+	queries []string
+	args    [][]any
+}
+
+func (q *recordQuerier) ExecContext(_ context.Context, query string, args ...any) (sql.Result, error) {
+	q.queries = append(q.queries, strings.Clone(query))
+	q.args = append(q.args, append([]any{}, args...))
+	return driver.RowsAffected(strings.Count(query, "(?")), nil
+}
+
+func (q *recordQuerier) QueryContext(context.Context, string, ...any) (*sql.Rows, error) {
+	return nil, errors.New("not supported")
+}
+
+func TestSQLStmt_MultiRow(t *testing.T) {
+	var sql string
+	var args []any
+	var err error
+
+	sql, args, err = sqlBuilderV3.InsertBulk(stuList).MultiRow().OnConflict("uid").DoNothing().Gen(w, db.SchPG)
+	assert.NoError(t, err)
+	assert.EqualValues(t, "INSERT INTO student (uid,username,nickname,email,age,enrolled,gpa,tokens,comp,create_time,update_time) "+
+		"VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11),($12,$13,$14,$15,$16,$17,$18,$19,$20,$21,$22) ON CONFLICT (uid) DO NOTHING", sql)
+	assert.EqualValues(t, append(append([]any{}, stuStructArr...), stuStructArr...), args)
+	assert.Len(t, w.BulkArgs(), 0)
+
+	// the rows are split by the bind parameter limit of SQLite, i.e., 32766 / 11 = 2978 rows
+	rows := make([]student, 6000)
+	for i := range rows {
+		rows[i] = stuStruct
+		rows[i].Uid = int64(i)
+	}
+	q := &recordQuerier{}
+	stmt := sqlBuilderV3.InsertBulk(rows)
+	affectedRows, err := stmt.ExecSQLite(q, context.Background())
+	assert.NoError(t, err)
+	assert.EqualValues(t, 6000, affectedRows)
+	assert.Len(t, q.queries, 3)
+	assert.Len(t, q.args[0], 2978*11)
+	assert.Len(t, q.args[1], 2978*11)
+	assert.Len(t, q.args[2], 44*11)
+	// the chunks run in order, and the slices are stored as JSON
+	assert.EqualValues(t, 2978, q.args[1][0])
+	assert.EqualValues(t, `["token1","token2"]`, q.args[2][7])
+	assert.EqualValues(t, 5999, q.args[2][43*11])
+	stmt.Destroy()
+
+	// the rows are split by the byte budget
+	budget := sqlBuilderV3.ChunkByteBudget
+	sqlBuilderV3.ChunkByteBudget = 1024
+	defer func() { sqlBuilderV3.ChunkByteBudget = budget }()
+
+	q = &recordQuerier{}
+	stmt = sqlBuilderV3.InsertBulk(rows[:10]).OnConflict().DoUpdateSet("age", "age + ??", 1)
+	affectedRows, err = stmt.ExecMySQL(q, context.Background())
+	assert.NoError(t, err)
+	assert.EqualValues(t, 10, affectedRows)
+	assert.Greater(t, len(q.queries), 1)
+	n := 0
+	for i, query := range q.queries {
+		assert.LessOrEqual(t, len(query), 1024)
+		assert.True(t, strings.HasSuffix(query, "ON DUPLICATE KEY UPDATE `age` = age + ?"))
+		// every chunk has the args of the upsert
+		assert.EqualValues(t, 1, q.args[i][len(q.args[i])-1])
+		n += len(q.args[i]) / 11
+	}
+	assert.EqualValues(t, 10, n)
+	stmt.Destroy()
+}
+
+func TestSQLStmt_Window(t *testing.T) {
+	var sql string
+	var args []any
+	var err error
+
+	// the select expressions are written in order along with the columns
+	stmt := sqlBuilderV3.Select().SelectColumns("uid", "name").
+		SelectExpr("ROW_NUMBER()").Over(sqlBuilderV3.Window().PartitionBy("grade").Desc("score")).As("rank").
+		SelectColumns("score").From("student").Where("age > ??", 18)
+	sql, args, err = stmt.Gen(w, db.SchPG)
+	assert.NoError(t, err)
+	assert.EqualValues(t, "SELECT uid,name,ROW_NUMBER() OVER (PARTITION BY grade ORDER BY score DESC) AS rank,score "+
+		"FROM student WHERE age > $1", sql)
+	assert.EqualValues(t, []any{18}, args)
+	stmt.Destroy()
+
+	// the args of the select expressions and the frame come before the ones of WHERE
+	stmt = sqlBuilderV3.Select().
+		SelectExpr("COALESCE(name, ??)", "N/A").As("name").
+		SelectExpr("SUM(score)").Over(sqlBuilderV3.Window().OrderBy("ts").
+		Frame("ROWS BETWEEN ?? PRECEDING AND CURRENT ROW", 3)).As("total").
+		From("student").Where("grade = ??", 2)
+	sql, args, err = stmt.Gen(w, db.SchPG)
+	assert.NoError(t, err)
+	assert.EqualValues(t, "SELECT COALESCE(name, $1) AS name,SUM(score) OVER (ORDER BY ts ROWS BETWEEN $2 PRECEDING AND CURRENT ROW) AS total "+
+		"FROM student WHERE grade = $3", sql)
+	assert.EqualValues(t, []any{"N/A", 3, 2}, args)
+	stmt.Destroy()
+
+	// named window after HAVING and before ORDER BY
+	stmt = sqlBuilderV3.Select().SelectColumns("grade").
+		SelectExpr("AVG(score)").Over("w").As("avg").
+		SelectExpr("MAX(score)").Over("w").
+		From("student").GroupBy("grade", "score").Having("COUNT(*) > ??", 1).
+		Window("w", sqlBuilderV3.Window().PartitionBy("grade").Frame("RANGE BETWEEN UNBOUNDED PRECEDING AND ?? FOLLOWING", 5)).
+		OrderBy("grade").Limit(10)
+	sql, args, err = stmt.Gen(w, db.SchPG)
+	assert.NoError(t, err)
+	assert.EqualValues(t, "SELECT grade,AVG(score) OVER w AS avg,MAX(score) OVER w FROM student GROUP BY grade, score HAVING COUNT(*) > $1 "+
+		"WINDOW w AS (PARTITION BY grade RANGE BETWEEN UNBOUNDED PRECEDING AND $2 FOLLOWING) ORDER BY grade LIMIT 10", sql)
+	assert.EqualValues(t, []any{1, 5}, args)
+	stmt.Destroy()
+
+	// strict mode validates the aliases and the columns of windows
+	stmt = sqlBuilderV3.Select().Strict().SelectExpr("RANK()").
+		Over(sqlBuilderV3.Window().PartitionBy("grade; DROP TABLE student").Asc("score")).
+		From("student")
+	_, _, err = stmt.Gen(w, db.SchPG)
+	assert.ErrorIs(t, err, sqlBuilderV3.ErrInvalidIdentifier)
+	stmt.Destroy()
+
+	stmt = sqlBuilderV3.Select().Strict().SelectExpr("RANK()").Over("w").As("r k").
+		From("student").Window("w", sqlBuilderV3.Window().Asc("score"))
+	_, _, err = stmt.Gen(w, db.SchPG)
+	assert.ErrorIs(t, err, sqlBuilderV3.ErrInvalidIdentifier)
+	stmt.Destroy()
+
+	// the columns of the window are quoted for MySQL
+	stmt = sqlBuilderV3.Select().SelectExpr("ROW_NUMBER()").
+		Over(sqlBuilderV3.Window().PartitionBy("S.group").OrderBy("`key`, order DESC").Asc("rank")).
+		From("student", "S")
+	sql, _, err = stmt.Gen(w, db.SchMYSQL)
+	assert.NoError(t, err)
+	assert.EqualValues(t, "SELECT ROW_NUMBER() OVER (PARTITION BY `S`.`group` ORDER BY `key` ASC,`order` DESC,`rank` ASC) "+
+		"FROM `student` AS S", sql)
+	stmt.Destroy()
+
+	// the window definition is owned by one statement or expression only
+	def := sqlBuilderV3.Window().PartitionBy("grade")
+	stmt = sqlBuilderV3.Select().SelectExpr("RANK()").Over(def).SelectExpr("DENSE_RANK()").Over(def).From("student")
+	_, _, err = stmt.Gen(w, db.SchPG)
+	assert.ErrorIs(t, err, sqlBuilderV3.ErrWindowShared)
+	stmt.Destroy()
+
+	def = sqlBuilderV3.Window().PartitionBy("grade")
+	stmt = sqlBuilderV3.Select().SelectExpr("RANK()").Over(def).From("student").Window("w", def)
+	_, _, err = stmt.Gen(w, db.SchPG)
+	assert.ErrorIs(t, err, sqlBuilderV3.ErrWindowShared)
+	stmt.Destroy()
+}
+
+func TestSQLStmt_Keyset(t *testing.T) {
+	var sql string
+	var args []any
+	var err error
+
+	type student struct {
+		Uid   int64  `db:"uid"`
+		Name  string `db:"name"`
+		Score int
+		Ts    time.Time `db:"ts"`
+	}
+	key := []byte("secret")
+
+	// row value comparison in the same direction
+	stmt := sqlBuilderV3.Select().SelectColumns("uid", "name").From("student").
+		Where("grade = ??", 3).Desc("Score", "uid").Limit(10)
+	cursor, err := stmt.CursorOf(&student{Uid: 42, Score: 90})
+	assert.NoError(t, err)
+	assert.EqualValues(t, []string{"Score", "uid"}, cursor.Cols)
+	assert.EqualValues(t, []any{90, int64(42)}, cursor.Values)
+
+	stmt.After(cursor)
+	sql, args, err = stmt.Gen(w, db.SchPG)
+	assert.NoError(t, err)
+	assert.EqualValues(t, "SELECT uid,name FROM student WHERE (grade = $1) AND ((Score,uid) < ($2,$3)) "+
+		"ORDER BY Score DESC, uid DESC LIMIT 10", sql)
+	assert.EqualValues(t, []any{3, 90, int64(42)}, args)
+
+	// Before reverses ORDER BY
+	stmt.Before(cursor)
+	sql, args, err = stmt.Gen(w, db.SchMYSQL)
+	assert.NoError(t, err)
+	assert.EqualValues(t, "SELECT `uid`,`name` FROM `student` WHERE (grade = ?) AND ((`Score`,`uid`) > (?,?)) "+
+		"ORDER BY `Score` ASC, `uid` ASC LIMIT 10", sql)
+	assert.EqualValues(t, []any{3, 90, int64(42)}, args)
+	stmt.Destroy()
+
+	// expanded comparison in mixed directions, and the cursor of a map
+	stmt = sqlBuilderV3.Select().From("student", "S").OrderBy("S.name").Desc("S.uid")
+	cursor, err = stmt.CursorOf(map[string]any{"name": "bob", "uid": 7})
+	assert.NoError(t, err)
+	sql, args, err = stmt.After(cursor).Gen(w, db.SchPG)
+	assert.NoError(t, err)
+	assert.EqualValues(t, "SELECT * FROM student AS S WHERE S.name > $1 OR (S.name = $2 AND S.uid < $3) "+
+		"ORDER BY S.name, S.uid DESC", sql)
+	assert.EqualValues(t, []any{"bob", "bob", 7}, args)
+	stmt.Destroy()
+
+	// the expressions of ORDER BY are kept as a whole
+	stmt = sqlBuilderV3.Select().From("student").OrderBy("COALESCE(nick, name) DESC nulls last, age + 1").
+		Before(&sqlBuilderV3.Cursor{Cols: []string{"COALESCE(nick, name)", "age + 1"}, Values: []any{"bob", 7}})
+	sql, args, err = stmt.Gen(w, db.SchPG)
+	assert.NoError(t, err)
+	assert.EqualValues(t, "SELECT * FROM student WHERE COALESCE(nick, name) > $1 OR "+
+		"(COALESCE(nick, name) = $2 AND age + 1 < $3) ORDER BY COALESCE(nick, name) ASC NULLS FIRST, age + 1 DESC", sql)
+	assert.EqualValues(t, []any{"bob", "bob", 7}, args)
+	stmt.Destroy()
+
+	// the cursor does not match ORDER BY
+	stmt = sqlBuilderV3.Select().From("student").Asc("uid").After(cursor)
+	_, _, err = stmt.Gen(w, db.SchPG)
+	assert.ErrorIs(t, err, sqlBuilderV3.ErrInvalidCursor)
+	stmt.Destroy()
+
+	// encoding round trip
+	ts := time.Date(2024, 5, 1, 8, 30, 0, 123456789, time.UTC)
+	stmt = sqlBuilderV3.Select().From("student").Asc("ts", "name", "uid")
+	cursor, err = stmt.CursorOf(student{Uid: 1 << 60, Name: "alice", Ts: ts})
+	assert.NoError(t, err)
+	token, err := cursor.Encode(key)
+	assert.NoError(t, err)
+
+	decoded, err := sqlBuilderV3.DecodeCursor(token, key)
+	assert.NoError(t, err)
+	assert.EqualValues(t, cursor.Cols, decoded.Cols)
+	assert.EqualValues(t, []any{ts, "alice", int64(1 << 60)}, decoded.Values)
+
+	sql, args, err = stmt.After(decoded).Gen(w, db.SchPG)
+	assert.NoError(t, err)
+	assert.EqualValues(t, "SELECT * FROM student WHERE (ts,name,uid) > ($1,$2,$3) ORDER BY ts ASC, name ASC, uid ASC", sql)
+	assert.EqualValues(t, []any{ts, "alice", int64(1 << 60)}, args)
+	stmt.Destroy()
+
+	// tampered or forged tokens are rejected
+	_, err = sqlBuilderV3.DecodeCursor(token, []byte("other"))
+	assert.ErrorIs(t, err, sqlBuilderV3.ErrInvalidCursor)
+	data, sig, _ := strings.Cut(token, ".")
+	tampered := []byte(data)
+	tampered[2] ^= 1
+	_, err = sqlBuilderV3.DecodeCursor(string(tampered)+"."+sig, key)
+	assert.ErrorIs(t, err, sqlBuilderV3.ErrInvalidCursor)
+	_, err = sqlBuilderV3.DecodeCursor("garbage", key)
+	assert.ErrorIs(t, err, sqlBuilderV3.ErrInvalidCursor)
+
+	// the token signed without a key could be forged by anyone
+	_, err = cursor.Encode(nil)
+	assert.ErrorIs(t, err, sqlBuilderV3.ErrNoCursorKey)
+	_, err = cursor.Encode([]byte{})
+	assert.ErrorIs(t, err, sqlBuilderV3.ErrNoCursorKey)
+	_, err = sqlBuilderV3.DecodeCursor(token, nil)
+	assert.ErrorIs(t, err, sqlBuilderV3.ErrNoCursorKey)
+}
+
+func TestSQLStmt_Lock(t *testing.T) {
+	var sql string
+	var args []any
+	var err error
+
+	// the locking clause is written after LIMIT
+	stmt := sqlBuilderV3.Select().SelectColumns("id", "payload").From("job").
+		Where("state = ??", "queued").Asc("id").Limit(10).ForUpdate().SkipLocked()
+	sql, args, err = stmt.Gen(w, db.SchPG)
+	assert.NoError(t, err)
+	assert.EqualValues(t, "SELECT id,payload FROM job WHERE state = $1 ORDER BY id ASC LIMIT 10 FOR UPDATE SKIP LOCKED", sql)
+	assert.EqualValues(t, []any{"queued"}, args)
+
+	sql, _, err = stmt.Gen(w, db.SchMYSQL)
+	assert.NoError(t, err)
+	assert.EqualValues(t, "SELECT `id`,`payload` FROM `job` WHERE state = ? ORDER BY `id` ASC LIMIT 10 FOR UPDATE SKIP LOCKED", sql)
+
+	_, _, err = stmt.Gen(w, db.SchSQLite)
+	assert.ErrorIs(t, err, sqlBuilderV3.ErrNotSupportDialectFeature)
+	stmt.Destroy()
+
+	stmt = sqlBuilderV3.Select().SelectColumns("A.balance").From("account", "A").
+		InnerJoin("customer", "C", "A.cid = C.id").
+		Where("A.id = ??", 1).Limit(1, 2).ForNoKeyUpdate().Of("A").NoWait()
+	sql, _, err = stmt.Gen(w, db.SchPG)
+	assert.NoError(t, err)
+	assert.EqualValues(t, "SELECT A.balance FROM account AS A INNER JOIN customer AS C ON A.cid = C.id WHERE A.id = $1 "+
+		"LIMIT 1 OFFSET 2 FOR NO KEY UPDATE OF A NOWAIT", sql)
+
+	// MySQL has no FOR NO KEY UPDATE
+	_, _, err = stmt.Gen(w, db.SchMYSQL)
+	assert.ErrorIs(t, err, sqlBuilderV3.ErrNotSupportDialectFeature)
+
+	stmt.ForShare()
+	sql, _, err = stmt.Gen(w, db.SchMYSQL)
+	assert.NoError(t, err)
+	assert.EqualValues(t, "SELECT `A`.`balance` FROM `account` AS A INNER JOIN `customer` AS C ON A.cid = C.id WHERE A.id = ? "+
+		"LIMIT 2,1 FOR SHARE OF `A` NOWAIT", sql)
+	stmt.Destroy()
+
+	// SKIP LOCKED without lock strength
+	stmt = sqlBuilderV3.Select().From("job").SkipLocked()
+	_, _, err = stmt.Gen(w, db.SchPG)
+	assert.ErrorIs(t, err, sqlBuilderV3.ErrNoLockStrength)
+	stmt.Destroy()
+}
+
+func TestSQLStmt_Distinct(t *testing.T) {
+	var sql string
+	var args []any
+	var err error
+
+	stmt := sqlBuilderV3.Select().Distinct().SelectColumns("grade").From("student").Where("age > ??", 18)
+	sql, args, err = stmt.Gen(w, db.SchMYSQL)
+	assert.NoError(t, err)
+	assert.EqualValues(t, "SELECT DISTINCT `grade` FROM `student` WHERE age > ?", sql)
+	assert.EqualValues(t, []any{18}, args)
+	stmt.Destroy()
+
+	// the latest session per user
+	stmt = sqlBuilderV3.Select().DistinctOn("uid").SelectColumns("uid", "sid", "ts").From("session").
+		Asc("uid").Desc("ts")
+	sql, _, err = stmt.Gen(w, db.SchPG)
+	assert.NoError(t, err)
+	assert.EqualValues(t, "SELECT DISTINCT ON (uid) uid,sid,ts FROM session ORDER BY uid ASC, ts DESC", sql)
+
+	// DISTINCT ON is only supported by Postgres
+	_, _, err = stmt.Gen(w, db.SchMYSQL)
+	assert.ErrorIs(t, err, sqlBuilderV3.ErrNotSupportDialectFeature)
+	_, _, err = stmt.Gen(w, db.SchSQLite)
+	assert.ErrorIs(t, err, sqlBuilderV3.ErrNotSupportDialectFeature)
+	stmt.Destroy()
+
+	// DISTINCT ON without ORDER BY
+	stmt = sqlBuilderV3.Select().DistinctOn("uid", "device").From("session")
+	sql, _, err = stmt.Gen(w, db.SchPG)
+	assert.NoError(t, err)
+	assert.EqualValues(t, "SELECT DISTINCT ON (uid,device) * FROM session", sql)
+
+	// the columns must lead ORDER BY
+	stmt.Desc("ts")
+	_, _, err = stmt.Gen(w, db.SchPG)
+	assert.ErrorIs(t, err, sqlBuilderV3.ErrDistinctOnOrder)
+	stmt.Destroy()
+
+	stmt = sqlBuilderV3.Select().DistinctOn("uid", "device").From("session").OrderBy("uid")
+	_, _, err = stmt.Gen(w, db.SchPG)
+	assert.ErrorIs(t, err, sqlBuilderV3.ErrDistinctOnOrder)
+	stmt.Destroy()
+
+	// the columns lead ORDER BY in any order, and the expressions are kept as a whole
+	stmt = sqlBuilderV3.Select().DistinctOn("device", "COALESCE(uid,0)").From("session").
+		OrderBy("COALESCE(uid,0), device DESC", "ts DESC")
+	sql, _, err = stmt.Gen(w, db.SchPG)
+	assert.NoError(t, err)
+	assert.EqualValues(t, "SELECT DISTINCT ON (device,COALESCE(uid,0)) * FROM session "+
+		"ORDER BY COALESCE(uid,0), device DESC, ts DESC", sql)
+	stmt.Destroy()
+}
+
+func TestSQLStmt_MultiTable(t *testing.T) {
+	var sql string
+	var args []any
+	var err error
+
+	// UPDATE ... FROM with a sub statement
+	stmt := sqlBuilderV3.Update("account").Set(sqlBuilderV3.Expr("balance = balance + T.amount")).
+		From(sqlBuilderV3.Select().SelectColumns("aid").SelectExpr("SUM(amount)").As("amount").
+			From("transfer").Where("state = ??", "pending").GroupBy("aid"), "T").
+		Where("account.id = T.aid AND account.state = ??", "active")
+	sql, args, err = stmt.Gen(w, db.SchPG)
+	assert.NoError(t, err)
+	assert.EqualValues(t, "UPDATE account SET balance = balance + T.amount "+
+		"FROM (SELECT aid,SUM(amount) AS amount FROM transfer WHERE state = $1 GROUP BY aid) AS T "+
+		"WHERE account.id = T.aid AND account.state = $2", sql)
+	assert.EqualValues(t, []any{"pending", "active"}, args)
+
+	sql, args, err = stmt.Gen(w, db.SchMYSQL)
+	assert.NoError(t, err)
+	assert.EqualValues(t, "UPDATE `account` JOIN (SELECT `aid`,SUM(amount) AS amount FROM `transfer` WHERE state = ? GROUP BY `aid`) AS T "+
+		"SET balance = balance + T.amount WHERE account.id = T.aid AND account.state = ?", sql)
+	assert.EqualValues(t, []any{"pending", "active"}, args)
+	stmt.Destroy()
+
+	// UPDATE ... FROM with joins
+	stmt = sqlBuilderV3.Update("student").Set(sqlBuilderV3.Map{"grade": 4}).From("enroll", "E").
+		InnerJoin("course", "C", "C.id = E.cid AND C.name = ??", "math").
+		Where("student.uid = E.uid").Returning("student.uid")
+	sql, args, err = stmt.Gen(w, db.SchSQLite)
+	assert.NoError(t, err)
+	assert.EqualValues(t, "UPDATE student SET grade = ? FROM enroll AS E INNER JOIN course AS C ON C.id = E.cid AND C.name = ? "+
+		"WHERE student.uid = E.uid RETURNING student.uid", sql)
+	assert.EqualValues(t, []any{4, "math"}, args)
+	stmt.Destroy()
+
+	// DELETE ... USING
+	stmt = sqlBuilderV3.Delete().From("session", "S").Using("users", "U").Using("ban").
+		Where("S.uid = U.uid AND U.uid = ban.uid AND ban.ts > ??", 100)
+	sql, args, err = stmt.Gen(w, db.SchPG)
+	assert.NoError(t, err)
+	assert.EqualValues(t, "DELETE FROM session AS S USING users AS U,ban WHERE S.uid = U.uid AND U.uid = ban.uid AND ban.ts > $1", sql)
+	assert.EqualValues(t, []any{100}, args)
+
+	sql, args, err = stmt.Gen(w, db.SchMYSQL)
+	assert.NoError(t, err)
+	assert.EqualValues(t, "DELETE S FROM `session` AS S JOIN `users` AS U JOIN `ban` WHERE S.uid = U.uid AND U.uid = ban.uid AND ban.ts > ?", sql)
+	assert.EqualValues(t, []any{100}, args)
+
+	// SQLite has no DELETE ... USING
+	_, _, err = stmt.Gen(w, db.SchSQLite)
+	assert.ErrorIs(t, err, sqlBuilderV3.ErrNotSupportDialectFeature)
+	stmt.Destroy()
+
+	stmt = sqlBuilderV3.Delete("session").LeftJoin("users", "U", "session.uid = U.uid").Where("U.uid IS NULL")
+	sql, _, err = stmt.Gen(w, db.SchMYSQL)
+	assert.NoError(t, err)
+	assert.EqualValues(t, "DELETE `session` FROM `session` LEFT JOIN `users` AS U ON session.uid = U.uid WHERE U.uid IS NULL", sql)
+
+	// the joins of Postgres need a source
+	_, _, err = stmt.Gen(w, db.SchPG)
+	assert.ErrorIs(t, err, sqlBuilderV3.ErrNoTableName)
+	stmt.Destroy()
+
+	// the target must be a table rather than a sub statement
+	stmt = sqlBuilderV3.Delete().From(sqlBuilderV3.Select("student"), "S").Where("S.uid = ??", 1)
+	_, _, err = stmt.Gen(w, db.SchPG)
+	assert.ErrorIs(t, err, sqlBuilderV3.ErrNotTableTarget)
+	stmt.Destroy()
+	stmt = sqlBuilderV3.Update().From(sqlBuilderV3.Select("student"), "S").Set("age", 18)
+	_, _, err = stmt.Gen(w, db.SchMYSQL)
+	assert.ErrorIs(t, err, sqlBuilderV3.ErrNotTableTarget)
+	stmt.Destroy()
+}
+
+type tagUser struct {
+	Uid      int64  `db:"uid,pk"`
+	Username string `db:"username"`
+	Nickname string `db:"nickname,omitempty"`
+	Serial   int64  `db:"serial,readonly"`
+	Cache    string `db:"-"`
+}
+
+func (tagUser) GetTableName() string {
+	return "users"
+}
+
+type tagMember struct {
+	Gid  int64 `db:"gid,pk"`
+	Uid  int64 `db:"uid,pk"`
+	Role string
+}
+
+func TestSQLStmt_TableModel(t *testing.T) {
+	var sql string
+	var args []any
+	var err error
+
+	user := tagUser{Uid: 1, Username: "alice", Serial: 9, Cache: "x"}
+
+	// "-" is skipped, and readonly is not inserted
+	stmt := sqlBuilderV3.Insert(&user)
+	sql, args, err = stmt.Gen(w, db.SchPG)
+	assert.NoError(t, err)
+	assert.EqualValues(t, "INSERT INTO users (uid,username,nickname) VALUES ($1,$2,$3)", sql)
+	assert.EqualValues(t, []any{int64(1), "alice", ""}, args)
+	stmt.Destroy()
+
+	// omitempty skips the zero value of Set
+	stmt = sqlBuilderV3.Update(&user).Where("uid = ??", 1)
+	sql, args, err = stmt.Gen(w, db.SchPG)
+	assert.NoError(t, err)
+	assert.EqualValues(t, "UPDATE users SET uid = $1,username = $2 WHERE uid = $3", sql)
+	assert.EqualValues(t, []any{int64(1), "alice", 1}, args)
+	stmt.Destroy()
+
+	stmt = sqlBuilderV3.Select(&user)
+	sql, _, err = stmt.Gen(w, db.SchPG)
+	assert.NoError(t, err)
+	assert.EqualValues(t, "SELECT uid,username,nickname,serial FROM users", sql)
+	stmt.Destroy()
+
+	meta, err := sqlBuilderV3.TableOf(user)
+	assert.NoError(t, err)
+	assert.EqualValues(t, "users", meta.Name)
+	assert.Len(t, meta.Columns, 4)
+	assert.EqualValues(t, "uid", meta.PK[0].Name)
+
+	stmt = sqlBuilderV3.FindByPK(&user)
+	sql, args, err = stmt.Gen(w, db.SchPG)
+	assert.NoError(t, err)
+	assert.EqualValues(t, "SELECT uid,username,nickname,serial FROM users WHERE uid = $1 LIMIT 1", sql)
+	assert.EqualValues(t, []any{int64(1)}, args)
+	stmt.Destroy()
+
+	stmt = sqlBuilderV3.ExistsByPK(tagUser{}, 5)
+	sql, args, err = stmt.Gen(w, db.SchMYSQL)
+	assert.NoError(t, err)
+	assert.EqualValues(t, "SELECT 1 FROM `users` WHERE `uid` = ? LIMIT 1", sql)
+	assert.EqualValues(t, []any{5}, args)
+	stmt.Destroy()
+
+	stmt = sqlBuilderV3.DeleteByPK(&user)
+	sql, args, err = stmt.Gen(w, db.SchPG)
+	assert.NoError(t, err)
+	assert.EqualValues(t, "DELETE FROM users WHERE uid = $1", sql)
+	assert.EqualValues(t, []any{int64(1)}, args)
+	stmt.Destroy()
+
+	// the non-zero fields, or the changed ones
+	stmt = sqlBuilderV3.UpdateByPK(&user)
+	sql, args, err = stmt.Gen(w, db.SchPG)
+	assert.NoError(t, err)
+	assert.EqualValues(t, "UPDATE users SET username = $1 WHERE uid = $2", sql)
+	assert.EqualValues(t, []any{"alice", int64(1)}, args)
+	stmt.Destroy()
+
+	stmt = sqlBuilderV3.UpdateByPK(&user, "nickname", "username")
+	sql, args, err = stmt.Gen(w, db.SchPG)
+	assert.NoError(t, err)
+	assert.EqualValues(t, "UPDATE users SET nickname = $1,username = $2 WHERE uid = $3", sql)
+	assert.EqualValues(t, []any{"", "alice", int64(1)}, args)
+	stmt.Destroy()
+
+	// the primary key and the read-only columns are not updated
+	stmt = sqlBuilderV3.UpdateByPK(&user, "serial")
+	_, _, err = stmt.Gen(w, db.SchPG)
+	assert.ErrorIs(t, err, sqlBuilderV3.ErrInvalidIdentifier)
+	stmt.Destroy()
+
+	stmt = sqlBuilderV3.UpdateByPK(&tagUser{Uid: 1})
+	_, _, err = stmt.Gen(w, db.SchPG)
+	assert.ErrorIs(t, err, sqlBuilderV3.ErrNoColumnToUpdate)
+	stmt.Destroy()
+
+	// composite primary key of the registered model
+	sqlBuilderV3.UnregisterTable(tagMember{})
+	defer sqlBuilderV3.UnregisterTable(tagMember{})
+	_, err = sqlBuilderV3.TableOf(tagMember{})
+	assert.ErrorIs(t, err, sqlBuilderV3.ErrNoTableName)
+	meta, err = sqlBuilderV3.RegisterTable(tagMember{}, "member")
+	assert.NoError(t, err)
+
+	// the model is registered once, and never renamed
+	again, err := sqlBuilderV3.RegisterTable(&tagMember{}, "member")
+	assert.NoError(t, err)
+	assert.Same(t, meta, again)
+	_, err = sqlBuilderV3.RegisterTable(tagMember{}, "team_member")
+	assert.ErrorIs(t, err, sqlBuilderV3.ErrTableRegistered)
+	again, err = sqlBuilderV3.TableOf(tagMember{})
+	assert.NoError(t, err)
+	assert.EqualValues(t, "member", again.Name)
+
+	stmt = sqlBuilderV3.FindByPK(tagMember{}, 2, 3)
+	sql, args, err = stmt.Gen(w, db.SchSQLite)
+	assert.NoError(t, err)
+	assert.EqualValues(t, "SELECT gid,uid,Role FROM member WHERE gid = ? AND uid = ? LIMIT 1", sql)
+	assert.EqualValues(t, []any{2, 3}, args)
+	stmt.Destroy()
+
+	stmt = sqlBuilderV3.DeleteByPK(tagMember{}, 2, 3)
+	sql, args, err = stmt.Gen(w, db.SchMYSQL)
+	assert.NoError(t, err)
+	assert.EqualValues(t, "DELETE FROM `member` WHERE `gid` = ? AND `uid` = ?", sql)
+	assert.EqualValues(t, []any{2, 3}, args)
+	stmt.Destroy()
+
+	stmt = sqlBuilderV3.DeleteByPK(tagMember{}, 2)
+	_, _, err = stmt.Gen(w, db.SchPG)
+	assert.ErrorIs(t, err, sqlBuilderV3.ErrNoPrimaryKey)
+	stmt.Destroy()
+}
+
+func TestSQLStmt_Compile(t *testing.T) {
+	stmt := sqlBuilderV3.Select(&tagUser{}).
+		Where(sqlBuilderV3.Map{"uid": sqlBuilderV3.Slot("uid")}).
+		Where("name = ?? OR nickname = ??", sqlBuilderV3.Slot("name"), sqlBuilderV3.Slot("name")).
+		Where("age > ??", 18).
+		Limit(10)
+	compiled, err := stmt.Compile(db.SchPG)
+	stmt.Destroy()
+	assert.NoError(t, err)
+	assert.EqualValues(t, "SELECT uid,username,nickname,serial FROM users "+
+		"WHERE (uid = $1) AND (name = $2 OR nickname = $2) AND (age > $3) LIMIT 10", compiled.SQL())
+	assert.EqualValues(t, db.SchPG, compiled.Schema())
+
+	// positional slots in the order they first appear, and the same name shares the position
+	args, err := compiled.Bind(1, "Alice")
+	assert.NoError(t, err)
+	assert.EqualValues(t, []any{1, "Alice", 18}, args)
+
+	args, err = compiled.BindMap(sqlBuilderV3.Map{"uid": 2, "name": "Bob"})
+	assert.NoError(t, err)
+	assert.EqualValues(t, []any{2, "Bob", 18}, args)
+
+	// the bound args are never shared
+	args[2] = 21
+	args, err = compiled.Bind(1, "Alice")
+	assert.NoError(t, err)
+	assert.EqualValues(t, []any{1, "Alice", 18}, args)
+
+	_, err = compiled.Bind(1)
+	assert.ErrorIs(t, err, sqlBuilderV3.ErrSlotCount)
+	_, err = compiled.BindMap(sqlBuilderV3.Map{"uid": 2})
+	assert.ErrorIs(t, err, sqlBuilderV3.ErrUnboundSlot)
+	assert.EqualError(t, err, "Slot not bound: name")
+
+	// the dialects without "$n" duplicate the args of the same name
+	stmt = sqlBuilderV3.Select().From("users").
+		Where("name = ?? OR nickname = ??", sqlBuilderV3.Slot("name"), sqlBuilderV3.Slot("name"))
+	compiled, err = stmt.Compile(db.SchMYSQL)
+	stmt.Destroy()
+	assert.NoError(t, err)
+	assert.EqualValues(t, "SELECT * FROM `users` WHERE name = ? OR nickname = ?", compiled.SQL())
+	args, err = compiled.Bind("Alice")
+	assert.NoError(t, err)
+	assert.EqualValues(t, []any{"Alice", "Alice"}, args)
+
+	// unnamed slots are bound by position only
+	stmt = sqlBuilderV3.Update("tag_user").
+		Set(sqlBuilderV3.Map{"nickname": sqlBuilderV3.Slot("")}).
+		Where("uid = ??", sqlBuilderV3.Slot(""))
+	compiled, err = stmt.Compile(db.SchMYSQL)
+	stmt.Destroy()
+	assert.NoError(t, err)
+	assert.EqualValues(t, "UPDATE `tag_user` SET `nickname` = ? WHERE uid = ?", compiled.SQL())
+	args, err = compiled.Bind("Ali", 1)
+	assert.NoError(t, err)
+	assert.EqualValues(t, []any{"Ali", 1}, args)
+
+	// the bulk insertion cannot be compiled
+	stmt = sqlBuilderV3.InsertBulk([]tagUser{{Uid: 1}, {Uid: 2}})
+	_, err = stmt.Compile(db.SchPG)
+	assert.ErrorIs(t, err, sqlBuilderV3.ErrNotSupportType)
+	stmt.Destroy()
+
+	stmt = sqlBuilderV3.Delete("tag_user", "uid = ??", 1).Returning("uid")
+	_, err = stmt.Compile(db.SchMYSQL)
+	assert.ErrorIs(t, err, sqlBuilderV3.ErrNotSupportDialectFeature)
+	stmt.Destroy()
+}
+
+func TestSQLStmt_NamedParams(t *testing.T) {
+	w := sqlBuilderV3.NewWriter()
+	defer w.Destroy()
+
+	params := sqlBuilderV3.Map{"name": "Alice", "uid": 1, "age": 18}
+	stmt := sqlBuilderV3.Select().From("users").
+		Where("uid = ??", 2).
+		Where("(username = :name OR nickname = @name) AND uid <> :uid", params).
+		Where("age > :age AND created::date < '2024-01-01 00:00:00' AND note <> ':name'", params)
+
+	sql, args, err := stmt.Gen(w, db.SchPG)
+	assert.NoError(t, err)
+	assert.EqualValues(t, "SELECT * FROM users WHERE (uid = $1) AND ((username = $2 OR nickname = $2) AND uid <> $3) "+
+		"AND (age > $4 AND created::date < '2024-01-01 00:00:00' AND note <> ':name')", sql)
+	assert.EqualValues(t, []any{2, "Alice", 1, 18}, args)
+
+	// the repeated names duplicate the args in MySQL
+	sql, args, err = stmt.Gen(w, db.SchMYSQL)
+	assert.NoError(t, err)
+	assert.EqualValues(t, "SELECT * FROM `users` WHERE (uid = ?) AND ((username = ? OR nickname = ?) AND uid <> ?) "+
+		"AND (age > ? AND created::date < '2024-01-01 00:00:00' AND note <> ':name')", sql)
+	assert.EqualValues(t, []any{2, "Alice", "Alice", 1, 18}, args)
+	stmt.Destroy()
+
+	// bound from a struct by the db tags
+	user := tagUser{Uid: 3, Username: "Bob"}
+	stmt = sqlBuilderV3.Select().From("users").Where("uid = :uid AND username = :username", &user)
+	sql, args, err = stmt.Gen(w, db.SchPG)
+	assert.NoError(t, err)
+	assert.EqualValues(t, "SELECT * FROM users WHERE uid = $1 AND username = $2", sql)
+	assert.EqualValues(t, []any{int64(3), "Bob"}, args)
+	stmt.Destroy()
+
+	// the missing name is reported by Gen
+	stmt = sqlBuilderV3.Select().From("users").Where("uid = :uid OR gid = :gid", sqlBuilderV3.Map{"uid": 1})
+	_, _, err = stmt.Gen(w, db.SchPG)
+	assert.ErrorIs(t, err, sqlBuilderV3.ErrUnboundParam)
+	assert.EqualError(t, err, "Named parameter not bound: gid")
+	stmt.Destroy()
+
+	// the named args of the upsert are bound in every row of the bulk insertion
+	stmt = sqlBuilderV3.InsertBulk(stuList).OnConflict("uid").
+		DoUpdateSet(sqlBuilderV3.Expr("age = student.age + :inc, gpa = :inc", sqlBuilderV3.Map{"inc": 10}))
+	sql, args, err = stmt.Gen(w, db.SchPG)
+	assert.NoError(t, err)
+	assert.EqualValues(t, "INSERT INTO student (uid,username,nickname,email,age,enrolled,gpa,tokens,comp,create_time,update_time) "+
+		"VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11) ON CONFLICT (uid) DO UPDATE SET age = student.age + $12, gpa = $12", sql)
+	assert.Len(t, args, 0)
+	assert.Len(t, w.BulkArgs(), 2)
+	for _, rowArgs := range w.BulkArgs() {
+		assert.EqualValues(t, append(append([]any{}, stuStructArr...), 10), *rowArgs)
+	}
+	stmt.Destroy()
+
+	stmt = sqlBuilderV3.InsertBulk(stuList).OnConflict("uid").
+		DoUpdateSet(sqlBuilderV3.Expr("age = student.age + :inc", sqlBuilderV3.Map{"step": 10}))
+	_, _, err = stmt.Gen(w, db.SchPG)
+	assert.EqualError(t, err, "Named parameter not bound: inc")
+	stmt.Destroy()
+
+	// a Map arg of "??" is not a named binding
+	cond := sqlBuilderV3.Expr("data = ??", sqlBuilderV3.Map{"a": 1})
+	assert.EqualValues(t, "data = ??", cond.String())
+	cond.Destroy()
+}
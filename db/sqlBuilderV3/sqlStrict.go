@@ -0,0 +1,227 @@
+package sqlBuilderV3
+
+import (
+	"strings"
+)
+
+// strictMode validates the table and column names, which may come from the
+// user input, e.g., the sort column of an API request.
+type strictMode struct {
+	enabled bool
+	// allowCols is the column allowlist, which is empty if not registered
+	allowCols map[string]struct{}
+	// cols is the columns of GroupBy, Set, Incr, Decr, DoUpdateSet and the keys
+	// of the Map of Where, which are validated by Gen, as Strict may be called
+	// after them.
+	cols []string
+	// err is the first rejected identifier found when building the statement
+	err error
+}
+
+func (s *strictMode) reset() {
+	s.enabled = false
+	clear(s.allowCols)
+	s.cols = s.cols[:0]
+	s.err = nil
+}
+
+// Strict enables the strict mode, in which Gen returns *IdentError if a table or
+// column name does not match the safe pattern, i.e., letters, digits and underscores
+// not starting with a digit, optionally qualified by dots, e.g., "S.uid". The names
+// given by IntoTable, From, Join, Of, SelectColumns, DistinctOn, Returning, GroupBy,
+// OrderBy, Desc, Asc, the keys of Map, the columns of the typed conditions like Eq
+// and InSub, and the aliases and windows of SelectExpr are validated, while the raw
+// SQL of Where, Expr and SelectExpr is not. The names are validated by Gen, so
+// Strict can be called at any time before it.
+func (stmt *Stmt) Strict() *Stmt {
+	stmt.strict.enabled = true
+	return stmt
+}
+
+// AllowColumns enables the strict mode and registers the column allowlist. Like
+// SelectColumns, it accepts column names or a struct to build the columns. Then the
+// columns not in the allowlist are rejected as well. A qualified column like "S.uid"
+// is allowed if either "S.uid" or "uid" is in the allowlist.
+func (stmt *Stmt) AllowColumns(column any, cols ...string) *Stmt {
+	stmt.strict.enabled = true
+	if stmt.strict.allowCols == nil {
+		stmt.strict.allowCols = make(map[string]struct{})
+	}
+
+	var colNames []string
+	switch column := column.(type) {
+	case []string:
+		colNames = column
+	case Columns:
+		colNames = column
+	case string:
+		stmt.strict.allowCols[column] = struct{}{}
+		colNames = cols
+	default:
+		buildColumns(&colNames, column)
+	}
+
+	for _, col := range colNames {
+		stmt.strict.allowCols[col] = struct{}{}
+	}
+	return stmt
+}
+
+// identError records the first rejected identifier
+func (stmt *Stmt) identError(name string, notAllowed bool) {
+	if stmt.strict.err == nil {
+		stmt.strict.err = &IdentError{Ident: name, NotAllowed: notAllowed}
+	}
+}
+
+// checkTable validates the table name or the alias in the strict mode
+func (stmt *Stmt) checkTable(name string) {
+	if !stmt.strict.enabled {
+		return
+	}
+	if !isPlainIdent(name) || strings.HasSuffix(name, "*") {
+		stmt.identError(name, false)
+	}
+}
+
+// checkCol validates the column name in the strict mode. "*" and "S.*" are
+// accepted as columns.
+func (stmt *Stmt) checkCol(name string) {
+	if !stmt.strict.enabled || name == "*" {
+		return
+	}
+	if !isPlainIdent(name) {
+		stmt.identError(name, false)
+		return
+	}
+
+	if len(stmt.strict.allowCols) == 0 || strings.HasSuffix(name, "*") {
+		return
+	}
+	if _, ok := stmt.strict.allowCols[name]; ok {
+		return
+	}
+	if i := strings.LastIndexByte(name, '.'); i >= 0 {
+		if _, ok := stmt.strict.allowCols[name[i+1:]]; ok {
+			return
+		}
+	}
+	stmt.identError(name, true)
+}
+
+func (stmt *Stmt) checkCols(names []string) {
+	for _, name := range names {
+		stmt.checkCol(name)
+	}
+}
+
+// checkOrder validates "col [ASC|DESC] [NULLS FIRST|NULLS LAST]" in the strict mode
+func (stmt *Stmt) checkOrder(order string) {
+	if !stmt.strict.enabled {
+		return
+	}
+
+	for _, item := range splitList(order) {
+		stmt.checkCol(parseOrder(item).col)
+	}
+}
+
+// keepCols keeps the columns to validate by Gen
+func (stmt *Stmt) keepCols(cols ...string) {
+	stmt.strict.cols = append(stmt.strict.cols, cols...)
+}
+
+// keepSetData keeps the columns of the input of Set to validate by Gen
+func (stmt *Stmt) keepSetData(data any) {
+	switch data := data.(type) {
+	case string:
+		stmt.keepCols(data)
+	case Map:
+		for col := range data {
+			stmt.keepCols(col)
+		}
+	case *condExpr:
+		// raw SQL is not validated
+	default:
+		buildColumns(&stmt.strict.cols, data)
+	}
+}
+
+// strictCheck validates the identifiers kept in the statement, and returns the
+// first rejected one.
+func (stmt *Stmt) strictCheck() error {
+	if !stmt.strict.enabled {
+		return nil
+	}
+
+	for _, item := range stmt.with {
+		stmt.checkTable(item.name)
+		stmt.checkCols(item.cols)
+	}
+	if len(stmt.tableInto) > 0 {
+		stmt.checkTable(stmt.tableInto)
+	}
+	for _, from := range stmt.tableFrom {
+		stmt.checkFromItem(from)
+	}
+	for _, join := range stmt.tableJoin {
+		stmt.checkFromItem(join.item)
+		stmt.checkCond(join.on)
+	}
+	stmt.checkCond(stmt.where)
+	stmt.checkCond(stmt.having)
+	stmt.checkCond(stmt.conflict.where)
+	stmt.checkCols(stmt.InsertCols)
+	stmt.checkCols(stmt.SelectCols)
+	stmt.checkCols(stmt.distinctOn)
+	stmt.checkCols(stmt.strict.cols)
+	for _, item := range stmt.orders {
+		stmt.checkCol(item.col)
+	}
+	stmt.checkWindows()
+	stmt.checkCols(stmt.ReturningCols)
+	stmt.checkCols(stmt.conflict.cols)
+	for _, table := range stmt.lock.of {
+		stmt.checkTable(table)
+	}
+
+	return stmt.strict.err
+}
+
+func (stmt *Stmt) checkFromItem(item fromItem) {
+	switch item := item.(type) {
+	case *fromTable:
+		stmt.checkTable(item.tableName)
+		if len(item.alias) > 0 {
+			stmt.checkTable(item.alias)
+		}
+	case *fromStmt:
+		if len(item.alias) > 0 {
+			stmt.checkTable(item.alias)
+		}
+	}
+}
+
+// checkCond validates the columns of the typed conditions, e.g., Eq and InSub. The
+// raw SQL of Expr is not validated, and the sub statements are validated by their
+// own strict mode.
+func (stmt *Stmt) checkCond(cond Cond) {
+	switch cond := cond.(type) {
+	case *condAnd:
+		for _, c := range *cond {
+			stmt.checkCond(c)
+		}
+	case *condOr:
+		for _, c := range *cond {
+			stmt.checkCond(c)
+		}
+	case *condNot:
+		stmt.checkCond(cond.cond)
+	case *condCompare:
+		stmt.checkCol(cond.col)
+	case *condSub:
+		if len(cond.col) > 0 {
+			stmt.checkCol(cond.col)
+		}
+	}
+}
@@ -0,0 +1,251 @@
+package sqlBuilderV3
+
+import (
+	"reflect"
+	"sync"
+
+	"github.com/secure-for-ai/secureai-microsvs/db"
+	"github.com/secure-for-ai/secureai-microsvs/util"
+)
+
+// ColumnMeta is the column mapped from a struct field by the db tag, e.g.,
+// `db:"uid,pk"`. The options are
+//
+//   - pk: the column is (a part of) the primary key.
+//   - omitempty: the zero value is skipped by Set, Update and UpdateByPK.
+//   - readonly: the column is written by the database only, e.g., a serial or
+//     generated column. It is selected, but never inserted or updated.
+//
+// The field tagged by `db:"-"` is not mapped to any column.
+type ColumnMeta struct {
+	Name string
+	// Index is the index of the struct field
+	Index     int
+	PK        bool
+	OmitEmpty bool
+	ReadOnly  bool
+}
+
+// structColumns is the columns of a struct type
+type structColumns struct {
+	cols  []ColumnMeta
+	names []string
+	// writable is the columns not read-only
+	writable      []ColumnMeta
+	writableNames []string
+	pk            []ColumnMeta
+}
+
+// getStructColumns parses the columns of the struct type, which are cached in
+// structColumnCache.
+func getStructColumns(vType reflect.Type) *structColumns {
+	if cols, ok := structColumnCache.Load(vType); ok {
+		return cols.(*structColumns)
+	}
+
+	numField := vType.NumField()
+	// avoid extend the slice cap which causes memory reallocation
+	s := &structColumns{
+		cols:  make([]ColumnMeta, 0, numField),
+		names: make([]string, 0, numField),
+	}
+	for i := 0; i < numField; i++ {
+		name, opts, skip := db.ParseTag(vType.Field(i))
+		if skip {
+			continue
+		}
+
+		col := ColumnMeta{
+			Name:      name,
+			Index:     i,
+			PK:        opts.Has(db.TagPK),
+			OmitEmpty: opts.Has(db.TagOmitEmpty),
+			ReadOnly:  opts.Has(db.TagReadOnly),
+		}
+		s.cols = append(s.cols, col)
+		s.names = append(s.names, name)
+		if !col.ReadOnly {
+			s.writable = append(s.writable, col)
+			s.writableNames = append(s.writableNames, name)
+		}
+		if col.PK {
+			s.pk = append(s.pk, col)
+		}
+	}
+
+	cols, _ := structColumnCache.LoadOrStore(vType, s)
+	return cols.(*structColumns)
+}
+
+// TableMeta is the table model registered by RegisterTable
+type TableMeta struct {
+	Name    string
+	Type    reflect.Type
+	Columns []ColumnMeta
+	// PK is the primary key columns
+	PK []ColumnMeta
+}
+
+var tableRegistry = sync.Map{}
+
+// RegisterTable registers the struct model of the table. If the name is not given,
+// the model must implement Table to provide the table name. Registering the model
+// again under the same name returns the registered metadata, and under another
+// name returns ErrTableRegistered.
+func RegisterTable(model any, name ...string) (*TableMeta, error) {
+	v := util.ReflectValue(model)
+	vType := v.Type()
+	if vType.Kind() != reflect.Struct {
+		return nil, ErrNotSupportType
+	}
+
+	meta := &TableMeta{Type: vType}
+	if len(name) > 0 {
+		meta.Name = name[0]
+	} else if table, ok := model.(Table); ok {
+		meta.Name = table.GetTableName()
+	} else {
+		return nil, ErrNoTableName
+	}
+
+	cols := getStructColumns(vType)
+	meta.Columns = cols.cols
+	meta.PK = cols.pk
+	if registered, loaded := tableRegistry.LoadOrStore(vType, meta); loaded {
+		meta = registered.(*TableMeta)
+		if len(name) > 0 && meta.Name != name[0] {
+			return nil, ErrTableRegistered
+		}
+	}
+	return meta, nil
+}
+
+// TableOf returns the metadata of the table model. The model implementing Table
+// is registered on the first use.
+func TableOf(model any) (*TableMeta, error) {
+	v := util.ReflectValue(model)
+	if meta, ok := tableRegistry.Load(v.Type()); ok {
+		return meta.(*TableMeta), nil
+	}
+	return RegisterTable(model)
+}
+
+// pkValues returns the values of the primary key, which are given by pk or
+// read from the model.
+func (meta *TableMeta) pkValues(model any, pk []any) ([]any, error) {
+	if len(meta.PK) == 0 {
+		return nil, ErrNoPrimaryKey
+	}
+	if len(pk) > 0 {
+		if len(pk) != len(meta.PK) {
+			return nil, ErrNoPrimaryKey
+		}
+		return pk, nil
+	}
+
+	v := util.ReflectValue(model)
+	values := make([]any, len(meta.PK))
+	for i, col := range meta.PK {
+		values[i] = valueInterface(v.Field(col.Index), false)
+	}
+	return values, nil
+}
+
+// byPK creates the statement of the table with "WHERE pk = ??" condition
+func byPK(stmt *Stmt, model any, pk []any) (*TableMeta, *Stmt) {
+	meta, err := TableOf(model)
+	if err != nil {
+		stmt.err = err
+		return nil, stmt
+	}
+	values, err := meta.pkValues(model, pk)
+	if err != nil {
+		stmt.err = err
+		return nil, stmt
+	}
+
+	stmt.From(meta.Name)
+	for i, col := range meta.PK {
+		// the key column is quoted for the dialect
+		cond := Eq(col.Name, values[i])
+		// self created cond is stored in the ref
+		stmt.whereRef = append(stmt.whereRef, cond)
+		stmt.Where(cond)
+	}
+	return meta, stmt
+}
+
+// FindByPK generate "SELECT cols FROM table WHERE pk = ?? LIMIT 1" of the registered
+// table model. The primary key is read from the model if pk is not given, so that the
+// model can be the result as well, e.g., FindByPK(&user).ExecPG(conn, ctx, &user).
+func FindByPK(model any, pk ...any) *Stmt {
+	meta, stmt := byPK(Select(), model, pk)
+	if meta != nil {
+		stmt.SelectColumns(getStructColumns(meta.Type).names)
+		stmt.Limit(1)
+	}
+	return stmt
+}
+
+// ExistsByPK generate "SELECT 1 FROM table WHERE pk = ?? LIMIT 1" of the registered
+// table model. The row exists if the executor returns one row.
+func ExistsByPK(model any, pk ...any) *Stmt {
+	meta, stmt := byPK(Select(), model, pk)
+	if meta != nil {
+		stmt.SelectColumns("1")
+		stmt.Limit(1)
+	}
+	return stmt
+}
+
+// DeleteByPK generate "DELETE FROM table WHERE pk = ??" of the registered table model
+func DeleteByPK(model any, pk ...any) *Stmt {
+	_, stmt := byPK(Delete(), model, pk)
+	return stmt
+}
+
+// UpdateByPK generate "UPDATE table SET cols WHERE pk = ??" of the registered table
+// model. Only the changed columns given by cols are updated, or the non-zero fields
+// if cols is not given. The primary key and the read-only columns are never updated.
+func UpdateByPK(model any, cols ...string) *Stmt {
+	meta, stmt := byPK(Update(), model, nil)
+	if meta == nil {
+		return stmt
+	}
+
+	v := util.ReflectValue(model)
+	set := func(col ColumnMeta) {
+		stmt.assigns.appendEq(col.Name, valueInterface(v.Field(col.Index), false))
+	}
+
+	if len(cols) == 0 {
+		for _, col := range getStructColumns(meta.Type).writable {
+			if !col.PK && !v.Field(col.Index).IsZero() {
+				set(col)
+			}
+		}
+	} else {
+		for _, name := range cols {
+			col, ok := meta.column(name)
+			if !ok || col.PK || col.ReadOnly {
+				stmt.err = &IdentError{Ident: name, NotAllowed: true}
+				return stmt
+			}
+			set(col)
+		}
+	}
+
+	if !stmt.assigns.IsValid() {
+		stmt.err = ErrNoColumnToUpdate
+	}
+	return stmt
+}
+
+func (meta *TableMeta) column(name string) (ColumnMeta, bool) {
+	for _, col := range meta.Columns {
+		if col.Name == name {
+			return col, true
+		}
+	}
+	return ColumnMeta{}, false
+}
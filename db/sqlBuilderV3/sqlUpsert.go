@@ -0,0 +1,196 @@
+package sqlBuilderV3
+
+import (
+	"github.com/secure-for-ai/secureai-microsvs/db"
+)
+
+// excludedPrefix refers to the row proposed for insertion in "ON CONFLICT DO UPDATE".
+// It is rewritten to "VALUES(col)" for MySQL.
+const excludedPrefix = "EXCLUDED."
+
+type conflictAction int
+
+const (
+	conflictNone conflictAction = iota
+	conflictDoNothing
+	conflictDoUpdate
+)
+
+type onConflict struct {
+	cols       []string
+	constraint string
+	action     conflictAction
+	setCols    *setList
+	where      Cond
+	// tracker internal created conds. reset() only destroy
+	// refed conds. This can avoid double free.
+	whereRef []Cond
+}
+
+func (c *onConflict) init() {
+	c.cols = []string{}
+	c.constraint = ""
+	c.action = conflictNone
+	c.setCols = new(setList)
+	c.where = condEmpty{}
+	c.whereRef = make([]Cond, 0, 2)
+}
+
+func (c *onConflict) reset() {
+	c.cols = c.cols[:0]
+	c.constraint = ""
+	c.action = conflictNone
+	c.setCols.Reset()
+	c.where = condEmpty{}
+	for _, cond := range c.whereRef {
+		cond.Destroy()
+	}
+	c.whereRef = c.whereRef[:0]
+}
+
+func (c *onConflict) isValid() bool {
+	return c.action != conflictNone || len(c.cols) > 0 || len(c.constraint) > 0
+}
+
+// Excluded generate "EXCLUDED.col", which refers to the value proposed for insertion
+// and can be used in DoUpdateSet, e.g., DoUpdateSet("nonce", Excluded("nonce")).
+func Excluded(col string) *condExpr {
+	expr := Expr(excludedPrefix)
+	expr.appendSql(col)
+	return expr
+}
+
+// OnConflict generate "ON CONFLICT (cols)" statement for insert. It is followed by
+// DoNothing or DoUpdateSet, and defaults to DO NOTHING if neither is given.
+func (stmt *Stmt) OnConflict(cols ...string) *Stmt {
+	stmt.conflict.cols = append(stmt.conflict.cols, cols...)
+	return stmt
+}
+
+// OnConstraint generate "ON CONFLICT ON CONSTRAINT name" statement for insert
+func (stmt *Stmt) OnConstraint(name string) *Stmt {
+	stmt.conflict.constraint = name
+	return stmt
+}
+
+// DoNothing generate "ON CONFLICT DO NOTHING" statement
+func (stmt *Stmt) DoNothing() *Stmt {
+	stmt.conflict.action = conflictDoNothing
+	return stmt
+}
+
+// DoUpdateSet generate "ON CONFLICT DO UPDATE SET col = {expr}" statement.
+// It accepts the same input as Set, and Excluded(col) refers to the value
+// proposed for insertion.
+func (stmt *Stmt) DoUpdateSet(data any, args ...any) *Stmt {
+	stmt.conflict.action = conflictDoUpdate
+	stmt.keepSetData(data)
+	setData(stmt.conflict.setCols, data, args...)
+	return stmt
+}
+
+// DoUpdateExcluded generate "ON CONFLICT DO UPDATE SET col = EXCLUDED.col" for each column
+func (stmt *Stmt) DoUpdateExcluded(cols ...string) *Stmt {
+	stmt.conflict.action = conflictDoUpdate
+	for _, col := range cols {
+		stmt.keepCols(col)
+		stmt.conflict.setCols.appendExcluded(col)
+	}
+	return stmt
+}
+
+// DoUpdateWhere generate "ON CONFLICT DO UPDATE SET ... WHERE cond" statement
+func (stmt *Stmt) DoUpdateWhere(query any, args ...any) *Stmt {
+	stmt.catCond(&stmt.conflict.where, &stmt.conflict.whereRef, And, query, args...)
+	return stmt
+}
+
+func (stmt *Stmt) conflictWriteTo(w *Writer) error {
+	c := &stmt.conflict
+	if !c.isValid() {
+		return nil
+	}
+
+	if w.schema == db.SchMYSQL {
+		return stmt.duplicateKeyWriteTo(w)
+	}
+
+	w.WriteString(" ON CONFLICT")
+	if len(c.cols) > 0 {
+		w.WriteString(" (")
+		w.Join(c.cols, ',')
+		w.WriteByte(')')
+	} else if len(c.constraint) > 0 {
+		// SQLite only accepts the columns as the conflict target
+		if w.schema == db.SchSQLite {
+			return ErrNotSupportDialectFeature
+		}
+		w.WriteString(" ON CONSTRAINT ")
+		w.WriteString(c.constraint)
+	}
+
+	if c.action != conflictDoUpdate {
+		w.WriteString(" DO NOTHING")
+		return nil
+	}
+
+	// DO UPDATE requires a conflict target
+	if len(c.cols) == 0 && len(c.constraint) == 0 {
+		return ErrNoConflictTarget
+	}
+	if !c.setCols.IsValid() {
+		return ErrNoColumnToUpdate
+	}
+
+	w.WriteString(" DO UPDATE SET ")
+	c.setCols.WriteTo(w)
+
+	if c.where.IsValid() {
+		w.WriteString(" WHERE ")
+		c.where.WriteTo(w)
+	}
+
+	return nil
+}
+
+// duplicateKeyWriteTo writes the upsert in MySQL, i.e., "ON DUPLICATE KEY UPDATE",
+// in which the conflict target is implied by the unique keys of the table.
+func (stmt *Stmt) duplicateKeyWriteTo(w *Writer) error {
+	c := &stmt.conflict
+
+	// MySQL cannot filter the update
+	if c.where.IsValid() {
+		return ErrNotSupportDialectFeature
+	}
+
+	w.WriteString(" ON DUPLICATE KEY UPDATE ")
+
+	if c.action != conflictDoUpdate {
+		// DO NOTHING is emulated by "col = col"
+		var col string
+		if len(c.cols) > 0 {
+			col = c.cols[0]
+		} else if len(stmt.InsertCols) > 0 {
+			col = stmt.InsertCols[0]
+		} else {
+			return ErrNoColumnToUpdate
+		}
+		w.WriteIdent(col)
+		w.WriteString(" = ")
+		w.WriteIdent(col)
+		return nil
+	}
+
+	if !c.setCols.IsValid() {
+		return ErrNoColumnToUpdate
+	}
+
+	// rewrite EXCLUDED.col to VALUES(col)
+	c.setCols.writeTo(w, true)
+	return nil
+}
+
+func isIdentByte(c byte) bool {
+	return c == '_' || c == '$' ||
+		('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z') || ('0' <= c && c <= '9')
+}
@@ -0,0 +1,317 @@
+package sqlBuilderV3
+
+import (
+	"sync"
+
+	"github.com/secure-for-ai/secureai-microsvs/db"
+)
+
+// WindowDef is the window definition of "OVER (PARTITION BY ... ORDER BY ... frame)"
+// and "WINDOW name AS (...)". It is owned by the statement it is added to, and
+// released by Stmt.Reset or Stmt.Destroy. Thus, it cannot be shared by statements,
+// and adding it twice fails the statement with ErrWindowShared.
+type WindowDef struct {
+	partition []string
+	order     []string
+	frame     *condExpr
+	// owned is set once the definition is added to a statement
+	owned bool
+}
+
+var windowDefPool = sync.Pool{
+	New: func() any {
+		return &WindowDef{
+			partition: make([]string, 0, 1),
+			order:     make([]string, 0, 1),
+		}
+	},
+}
+
+// Window creates a window definition
+func Window() *WindowDef {
+	win := windowDefPool.Get().(*WindowDef)
+	win.frame = Expr("")
+	return win
+}
+
+// PartitionBy generate "PARTITION BY cols"
+func (win *WindowDef) PartitionBy(cols ...string) *WindowDef {
+	win.partition = append(win.partition, cols...)
+	return win
+}
+
+// OrderBy generate "ORDER BY order"
+func (win *WindowDef) OrderBy(order ...string) *WindowDef {
+	win.order = append(win.order, order...)
+	return win
+}
+
+// Asc generate "ORDER BY col ASC"
+func (win *WindowDef) Asc(cols ...string) *WindowDef {
+	for _, col := range cols {
+		win.order = append(win.order, col+" ASC")
+	}
+	return win
+}
+
+// Desc generate "ORDER BY col DESC"
+func (win *WindowDef) Desc(cols ...string) *WindowDef {
+	for _, col := range cols {
+		win.order = append(win.order, col+" DESC")
+	}
+	return win
+}
+
+// Frame sets the frame clause, e.g., Frame("ROWS BETWEEN ?? PRECEDING AND CURRENT ROW", 3)
+func (win *WindowDef) Frame(frame string, args ...any) *WindowDef {
+	win.frame.Reset()
+	win.frame.set(frame, args...)
+	return win
+}
+
+func (win *WindowDef) writeTo(w *Writer) {
+	w.WriteByte('(')
+	sep := false
+	if len(win.partition) > 0 {
+		w.WriteString("PARTITION BY ")
+		w.JoinIdent(win.partition, ',')
+		sep = true
+	}
+	if len(win.order) > 0 {
+		if sep {
+			w.WriteByte(' ')
+		}
+		w.WriteString("ORDER BY ")
+		win.orderWriteTo(w)
+		sep = true
+	}
+	if win.frame.IsValid() {
+		if sep {
+			w.WriteByte(' ')
+		}
+		win.frame.WriteTo(w)
+	}
+	w.WriteByte(')')
+}
+
+// orderWriteTo writes the ORDER BY items, whose columns are quoted for MySQL
+func (win *WindowDef) orderWriteTo(w *Writer) {
+	if w.schema != db.SchMYSQL {
+		w.Join(win.order, ',')
+		return
+	}
+
+	i := 0
+	for _, order := range win.order {
+		for _, item := range splitList(order) {
+			if i > 0 {
+				w.WriteByte(',')
+			}
+			parseOrder(item).writeTo(w, false)
+			i++
+		}
+	}
+}
+
+// own marks the definition as added to a statement, and reports whether it is
+// already added to one
+func (win *WindowDef) own() bool {
+	if win.owned {
+		return false
+	}
+	win.owned = true
+	return true
+}
+
+// Destroy releases the window definition, which is not added to any statement
+func (win *WindowDef) Destroy() {
+	win.owned = false
+	win.partition = win.partition[:0]
+	win.order = win.order[:0]
+	win.frame.Destroy()
+	win.frame = nil
+	windowDefPool.Put(win)
+}
+
+// selectExpr is the select expression "expr [OVER window] [AS alias]", which
+// is written in front of the column SelectCols[pos].
+type selectExpr struct {
+	pos        int
+	expr       *condExpr
+	window     *WindowDef
+	windowName string
+	alias      string
+}
+
+var selectExprPool = sync.Pool{
+	New: func() any {
+		return new(selectExpr)
+	},
+}
+
+func (expr *selectExpr) writeTo(w *Writer) {
+	expr.expr.WriteTo(w)
+	if expr.window != nil {
+		w.WriteString(" OVER ")
+		expr.window.writeTo(w)
+	} else if len(expr.windowName) > 0 {
+		w.WriteString(" OVER ")
+		w.WriteString(expr.windowName)
+	}
+	if len(expr.alias) > 0 {
+		w.WriteString(" AS ")
+		w.WriteString(expr.alias)
+	}
+}
+
+func (expr *selectExpr) destroy() {
+	expr.expr.Destroy()
+	expr.expr = nil
+	if expr.window != nil {
+		expr.window.Destroy()
+		expr.window = nil
+	}
+	expr.windowName = ""
+	expr.alias = ""
+	selectExprPool.Put(expr)
+}
+
+type namedWindow struct {
+	name string
+	def  *WindowDef
+}
+
+// SelectExpr appends the select expression with args, e.g., SelectExpr("COALESCE(nickname, ??)", "N/A").
+// It is written in order along with the columns of SelectColumns, and is followed by Over and As.
+func (stmt *Stmt) SelectExpr(expr string, args ...any) *Stmt {
+	item := selectExprPool.Get().(*selectExpr)
+	item.pos = len(stmt.SelectCols)
+	item.expr = Expr(expr, args...)
+	stmt.selectExprs = append(stmt.selectExprs, item)
+	return stmt
+}
+
+// Over generate "expr OVER (window)" for the last select expression. The window is
+// either a *WindowDef or the name of the window defined by Stmt.Window.
+func (stmt *Stmt) Over(window any) *Stmt {
+	if len(stmt.selectExprs) == 0 {
+		return stmt
+	}
+
+	item := stmt.selectExprs[len(stmt.selectExprs)-1]
+	switch window := window.(type) {
+	case *WindowDef:
+		if !window.own() {
+			stmt.shareWindow()
+			return stmt
+		}
+		if item.window != nil {
+			item.window.Destroy()
+		}
+		item.window = window
+	case string:
+		item.windowName = window
+	}
+	return stmt
+}
+
+// As generate "expr AS alias" for the last select expression
+func (stmt *Stmt) As(alias string) *Stmt {
+	if len(stmt.selectExprs) == 0 {
+		return stmt
+	}
+	stmt.selectExprs[len(stmt.selectExprs)-1].alias = alias
+	return stmt
+}
+
+// Window generate "WINDOW name AS (window)" statement, which can be referred by Over(name)
+func (stmt *Stmt) Window(name string, window *WindowDef) *Stmt {
+	if window == nil {
+		return stmt
+	}
+	if !window.own() {
+		stmt.shareWindow()
+		return stmt
+	}
+	stmt.windows = append(stmt.windows, namedWindow{name, window})
+	return stmt
+}
+
+// shareWindow fails the statement adding the window definition owned by another
+// statement or expression, which would be released twice
+func (stmt *Stmt) shareWindow() {
+	if stmt.err == nil {
+		stmt.err = ErrWindowShared
+	}
+}
+
+// selectColsWriteTo writes the columns and the select expressions in order
+func (stmt *Stmt) selectColsWriteTo(w *Writer) {
+	if len(stmt.SelectCols) == 0 && len(stmt.selectExprs) == 0 {
+		w.WriteByte('*')
+		return
+	}
+
+	i := 0
+	for j, col := range stmt.SelectCols {
+		for ; i < len(stmt.selectExprs) && stmt.selectExprs[i].pos <= j; i++ {
+			if i > 0 || j > 0 {
+				w.WriteByte(',')
+			}
+			stmt.selectExprs[i].writeTo(w)
+		}
+		if i > 0 || j > 0 {
+			w.WriteByte(',')
+		}
+		w.WriteIdent(col)
+	}
+	for ; i < len(stmt.selectExprs); i++ {
+		if i > 0 || len(stmt.SelectCols) > 0 {
+			w.WriteByte(',')
+		}
+		stmt.selectExprs[i].writeTo(w)
+	}
+}
+
+func (stmt *Stmt) windowWriteTo(w *Writer) {
+	if len(stmt.windows) == 0 {
+		return
+	}
+
+	w.WriteString(" WINDOW ")
+	for i, win := range stmt.windows {
+		if i > 0 {
+			w.WriteString(", ")
+		}
+		w.WriteString(win.name)
+		w.WriteString(" AS ")
+		win.def.writeTo(w)
+	}
+}
+
+// checkWindows validates the identifiers of the select expressions and the windows
+// in the strict mode
+func (stmt *Stmt) checkWindows() {
+	check := func(win *WindowDef) {
+		stmt.checkCols(win.partition)
+		for _, o := range win.order {
+			stmt.checkOrder(o)
+		}
+	}
+
+	for _, item := range stmt.selectExprs {
+		if len(item.alias) > 0 {
+			stmt.checkTable(item.alias)
+		}
+		if len(item.windowName) > 0 {
+			stmt.checkTable(item.windowName)
+		}
+		if item.window != nil {
+			check(item.window)
+		}
+	}
+	for _, win := range stmt.windows {
+		stmt.checkTable(win.name)
+		check(win.def)
+	}
+}
@@ -0,0 +1,109 @@
+package sqlBuilderV3
+
+import (
+	"sync"
+)
+
+var withItemPool = sync.Pool{
+	New: func() any {
+		return new(withItem)
+	},
+}
+
+// withItem is a common table expression: "name (cols) AS (stmt)". The recursive
+// one is "name (cols) AS (stmt UNION ALL recursive)".
+type withItem struct {
+	name      string
+	cols      []string
+	stmt      *Stmt
+	recursive *Stmt
+}
+
+func createWithItem(name string, cols []string, stmt *Stmt, recursive *Stmt) *withItem {
+	item := withItemPool.Get().(*withItem)
+	item.name = name
+	item.cols = append(item.cols[:0], cols...)
+	item.stmt = stmt
+	item.recursive = recursive
+	return item
+}
+
+func (item *withItem) writeTo(w *Writer) error {
+	w.WriteString(item.name)
+	if len(item.cols) > 0 {
+		w.WriteString(" (")
+		w.Join(item.cols, ',')
+		w.WriteByte(')')
+	}
+	w.WriteString(" AS (")
+
+	if err := item.stmt.WriteTo(w); err != nil {
+		return err
+	}
+
+	if item.recursive != nil {
+		w.WriteString(" UNION ALL ")
+		if err := item.recursive.WriteTo(w); err != nil {
+			return err
+		}
+	}
+
+	w.WriteByte(')')
+	return nil
+}
+
+func (item *withItem) destroy() {
+	item.stmt.Destroy()
+	item.stmt = nil
+	if item.recursive != nil {
+		item.recursive.Destroy()
+		item.recursive = nil
+	}
+	withItemPool.Put(item)
+}
+
+// With generate "WITH name AS (stmt)" statement in front of select, insert, update
+// and delete. The sub statement is destroyed along with the statement.
+func (stmt *Stmt) With(name string, sub *Stmt, cols ...string) *Stmt {
+	if sub == nil {
+		return stmt
+	}
+	stmt.with = append(stmt.with, createWithItem(name, cols, sub, nil))
+	return stmt
+}
+
+// WithRecursive generate "WITH RECURSIVE name (cols) AS (anchor UNION ALL recursive)"
+// statement. Both sub statements are destroyed along with the statement.
+func (stmt *Stmt) WithRecursive(name string, cols []string, anchor *Stmt, recursive *Stmt) *Stmt {
+	if anchor == nil || recursive == nil {
+		return stmt
+	}
+	stmt.with = append(stmt.with, createWithItem(name, cols, anchor, recursive))
+	return stmt
+}
+
+func (stmt *Stmt) withWriteTo(w *Writer) error {
+	if len(stmt.with) == 0 {
+		return nil
+	}
+
+	w.WriteString("WITH ")
+	for _, item := range stmt.with {
+		if item.recursive != nil {
+			w.WriteString("RECURSIVE ")
+			break
+		}
+	}
+
+	for i, item := range stmt.with {
+		if i > 0 {
+			w.WriteString(", ")
+		}
+		if err := item.writeTo(w); err != nil {
+			return err
+		}
+	}
+
+	w.WriteByte(' ')
+	return nil
+}
@@ -2,6 +2,8 @@ package sqlBuilderV3
 
 import (
 	"sync"
+
+	"github.com/secure-for-ai/secureai-microsvs/db"
 )
 
 var argsPool = sync.Pool{
@@ -22,14 +24,20 @@ type Writer struct {
 	*stringWriter
 	args     []any
 	bulkArgs []*[]any
+	// schema is the target dialect of the SQL, and it is set by Gen.
+	// The zero value means no specific dialect.
+	schema db.Schema
+	// err is the first error of the sub statements written by the conditions and
+	// the from items, which cannot return it. It is returned by Gen.
+	err error
 }
 
 var writerPool = sync.Pool{
 	New: func() any {
 		w := &Writer{
 			stringWriter: &stringWriter{},
-			args:     make([]any, 0, 4),
-			bulkArgs: make([]*[]any, 0, 4),
+			args:         make([]any, 0, 4),
+			bulkArgs:     make([]*[]any, 0, 4),
 		}
 		w.Grow(128)
 		return w
@@ -67,6 +75,20 @@ func (w *Writer) Reset() {
 		argsPool.Put(args)
 	}
 	w.bulkArgs = w.bulkArgs[:0]
+	w.schema = 0
+	w.err = nil
+}
+
+// setErr records the first error of a sub statement
+func (w *Writer) setErr(err error) {
+	if w.err == nil {
+		w.err = err
+	}
+}
+
+// Schema returns the dialect the SQL is generated for
+func (w *Writer) Schema() db.Schema {
+	return w.schema
 }
 
 func (w *Writer) Destroy() {
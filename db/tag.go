@@ -0,0 +1,81 @@
+package db
+
+import (
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// Options of the db tag
+const (
+	// TagPK marks the primary key column
+	TagPK = "pk"
+	// TagOmitEmpty skips the zero value when the struct is used to set columns
+	TagOmitEmpty = "omitempty"
+	// TagReadOnly marks the column written by the database only, e.g., a serial
+	// or generated column, which is never inserted or updated from the struct
+	TagReadOnly = "readonly"
+)
+
+// TagOptions is the comma-separated options of the db tag after the column name
+type TagOptions string
+
+// Has checks whether the option is set
+func (o TagOptions) Has(opt string) bool {
+	s := string(o)
+	for s != "" {
+		var name string
+		name, s, _ = strings.Cut(s, ",")
+		if name == opt {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseTag parses the db tag of the field, e.g., `db:"uid,pk"`. The column name is
+// the field name if it is not given in the tag, and skip reports that the field is
+// tagged by `db:"-"` and not mapped to any column.
+func ParseTag(field reflect.StructField) (name string, opts TagOptions, skip bool) {
+	tag := field.Tag.Get(Tag)
+	if tag == "-" {
+		return "", "", true
+	}
+
+	name, o, _ := strings.Cut(tag, ",")
+	if name == "" {
+		name = field.Name
+	}
+	return name, TagOptions(o), false
+}
+
+// fieldIndexCache caches the column names to the field indexes by the struct type
+var fieldIndexCache = sync.Map{}
+
+// FieldMap maps the columns of the rows to the indexes of the fields of the struct
+// t by the db tags. The column not mapped to any field is -1, so is the one of the
+// field tagged by `db:"-"`.
+func FieldMap(t reflect.Type, cols []string) []int {
+	var fieldIndex map[string]int
+	if cached, ok := fieldIndexCache.Load(t); ok {
+		fieldIndex = cached.(map[string]int)
+	} else {
+		fieldIndex = make(map[string]int, t.NumField())
+		for i := 0; i < t.NumField(); i++ {
+			if name, _, skip := ParseTag(t.Field(i)); !skip {
+				fieldIndex[name] = i
+			}
+		}
+		fieldIndexCache.Store(t, fieldIndex)
+	}
+
+	fieldMap := make([]int, len(cols))
+	for i, col := range cols {
+		if index, ok := fieldIndex[col]; ok {
+			fieldMap[i] = index
+		} else {
+			fieldMap[i] = -1
+		}
+	}
+	return fieldMap
+}
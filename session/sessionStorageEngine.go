@@ -10,6 +10,7 @@ import (
 	"github.com/secure-for-ai/secureai-microsvs/cache"
 	"github.com/secure-for-ai/secureai-microsvs/db/mongodb"
 	"github.com/secure-for-ai/secureai-microsvs/db/pgdb"
+	"github.com/secure-for-ai/secureai-microsvs/db/sqlBuilderV3"
 	"github.com/secure-for-ai/secureai-microsvs/util"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo/options"
@@ -230,15 +231,15 @@ type RedisMongoStoreEngine struct {
 }
 
 type SessValue struct {
-	Sid        int64          `json:"sid"        bson:"sid"         pg:"sid"`
-	Uid        int64          `json:"uid"        bson:"uid"         pg:"uid"`
-	Nonce      []byte         `json:"nonce"      bson:"nonce"       pg:"nonce"`
-	Data       map[string]any `json:"data"       bson:"data"        pg:"data"`
-	IP         net.IP         `json:"ip"         bson:"ip"          pg:"ip"`
-	UserAgent  string         `json:"userAgent"  bson:"user_agent"  pg:"user_agent"`
-	CreateTime int64          `json:"createTime" bson:"create_time" pg:"create_time"`
-	UpdateTime int64          `json:"updateTime" bson:"update_time" pg:"update_time"`
-	ExpireTime int64          `json:"expireTime" bson:"expire_time" pg:"expire_time"`
+	Sid        int64          `json:"sid"        bson:"sid"         pg:"sid"         db:"sid"`
+	Uid        int64          `json:"uid"        bson:"uid"         pg:"uid"         db:"uid"`
+	Nonce      []byte         `json:"nonce"      bson:"nonce"       pg:"nonce"       db:"nonce"`
+	Data       map[string]any `json:"data"       bson:"data"        pg:"data"        db:"data"`
+	IP         net.IP         `json:"ip"         bson:"ip"          pg:"ip"          db:"ip"`
+	UserAgent  string         `json:"userAgent"  bson:"user_agent"  pg:"user_agent"  db:"user_agent"`
+	CreateTime int64          `json:"createTime" bson:"create_time" pg:"create_time" db:"create_time"`
+	UpdateTime int64          `json:"updateTime" bson:"update_time" pg:"update_time" db:"update_time"`
+	ExpireTime int64          `json:"expireTime" bson:"expire_time" pg:"expire_time" db:"expire_time"`
 }
 
 func (r *RedisMongoStoreEngine) init() error { return nil }
@@ -508,21 +509,12 @@ func (r *RedisPGStoreEngine) save(ctx context.Context, sess *sessions.Session, d
 		return err
 	}
 	defer conn.Release()
-	result, err := conn.Update(
-		ctx,
-		"INSERT INTO "+r.Table+" (sid, uid, nonce, data, ip, "+
-			"user_agent, create_time, update_time, expire_time) VALUES "+
-			"($1, $2, $3, $4, $5, $6, $7, $8, $9) "+
-			"ON CONFLICT (sid, uid) "+
-			"Do UPDATE SET nonce=EXCLUDED.nonce, data=EXCLUDED.data, "+
-			"ip=EXCLUDED.ip, user_agent=EXCLUDED.user_agent, "+
-			"create_time=EXCLUDED.create_time, update_time=EXCLUDED.update_time,"+
-			"expire_time=EXCLUDED.expire_time;",
-		sessValue.Sid,
-		sessValue.Uid, sessValue.Nonce, sessValue.Data, sessValue.IP,
-		sessValue.UserAgent, sessValue.CreateTime, sessValue.UpdateTime,
-		sessValue.ExpireTime,
-	)
+	stmt := sqlBuilderV3.Insert().IntoTable(r.Table).Values(&sessValue).
+		OnConflict("sid", "uid").
+		DoUpdateExcluded("nonce", "data", "ip", "user_agent",
+			"create_time", "update_time", "expire_time")
+	result, err := stmt.ExecPG(conn, ctx)
+	stmt.Destroy()
 	// there is an error on upsert
 	if err != nil {
 		log.Fatal(err)
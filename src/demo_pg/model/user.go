@@ -6,16 +6,17 @@ import (
 	"strconv"
 	"template2/demo_pg/config"
 	"template2/demo_pg/constant"
+	"template2/lib/db/sqlBuilderV3"
 	"template2/lib/util"
 )
 
 type UserInfo struct {
-	UID        int64  `pg:"uid,omitempty" json:"uid,omitempty"`
-	Username   string `pg:"username" json:"username"`      // username
-	Nickname   string `pg:"nickname" json:"nickname"`      // nickname
-	Email      string `pg:"email" json:"email"`            // email
-	CreateTime int64  `pg:"create_time" json:"createTime"` // create time
-	UpdateTime int64  `pg:"update_time" json:"updateTime"` // update time
+	UID        int64  `db:"uid,pk" pg:"uid,omitempty" json:"uid,omitempty"`
+	Username   string `db:"username" pg:"username" json:"username"`         // username
+	Nickname   string `db:"nickname" pg:"nickname" json:"nickname"`         // nickname
+	Email      string `db:"email" pg:"email" json:"email"`                  // email
+	CreateTime int64  `db:"create_time" pg:"create_time" json:"createTime"` // create time
+	UpdateTime int64  `db:"update_time" pg:"update_time" json:"updateTime"` // update time
 }
 
 // Helpers --------------------------------------------------------------------
@@ -24,6 +25,10 @@ func init() {
 	gob.Register(UserInfo{})
 }
 
+func (UserInfo) GetTableName() string {
+	return constant.TableUser
+}
+
 /* API used by Graph QL */
 func CreateUser(user *UserInfo) error {
 	user.UID = config.SnowflakeNode.Generate().Int64()
@@ -38,11 +43,10 @@ func CreateUser(user *UserInfo) error {
 	}
 	defer conn.Release()
 
-	count, err := conn.Insert(ctx,
-		"INSERT INTO "+constant.TableUser+
-			" (uid, username, nickname, email, create_time, update_time)"+
-			" VALUES ($1, $2, $3, $4, $5, $6) ON CONFLICT (username) DO NOTHING;",
-		user.UID, user.Username, user.Nickname, user.Email, user.CreateTime, user.UpdateTime)
+	stmt := sqlBuilderV3.Insert().IntoTable(constant.TableUser).Values(user).
+		OnConflict("username").DoNothing()
+	count, err := stmt.ExecPG(conn, ctx)
+	stmt.Destroy()
 	if err != nil {
 		return err
 	}
@@ -65,7 +69,9 @@ func GetUser(username string) (user *UserInfo, err error) {
 	}
 	defer conn.Release()
 
-	err = conn.FindOne(ctx, "SELECT * FROM "+constant.TableUser+" WHERE username=$1;", user, username)
+	stmt := sqlBuilderV3.Select(user).Where("username = ??", username)
+	_, err = stmt.ExecPG(conn, ctx, user)
+	stmt.Destroy()
 
 	if err != nil {
 		return nil, err
@@ -80,7 +86,7 @@ func GetUserById(id string) (user *UserInfo, err error) {
 		return user, constant.ErrParamIDFormatWrong
 	}
 
-	user = &UserInfo{}
+	user = &UserInfo{UID: uid}
 	client := config.PGClient
 	ctx := context.Background()
 	conn, err := client.GetConn(ctx)
@@ -90,7 +96,9 @@ func GetUserById(id string) (user *UserInfo, err error) {
 	}
 	defer conn.Release()
 
-	err = conn.FindOne(ctx, "SELECT * FROM "+constant.TableUser+" WHERE uid=$1;", user, uid)
+	stmt := sqlBuilderV3.FindByPK(user)
+	_, err = stmt.ExecPG(conn, ctx, user)
+	stmt.Destroy()
 
 	if err != nil {
 		return nil, err
@@ -108,10 +116,9 @@ func UpdateUser(user *UserInfo) error {
 	}
 	defer conn.Release()
 
-	_, err = conn.Update(ctx,
-		"UPDATE "+constant.TableUser+
-			" SET username=$1, nickname=$2, email=$3, update_time=$4 WHERE uid=$5;",
-		user.Username, user.Nickname, user.Email, user.UpdateTime, user.UID)
+	stmt := sqlBuilderV3.UpdateByPK(user, "username", "nickname", "email", "update_time")
+	_, err = stmt.ExecPG(conn, ctx)
+	stmt.Destroy()
 	if err != nil {
 		return err
 	}
@@ -133,7 +140,9 @@ func DeleteUser(id string) error {
 	}
 	defer conn.Release()
 
-	_, err = conn.Update(ctx, "DELETE FROM "+constant.TableUser+" WHERE uid=$1;", uid)
+	stmt := sqlBuilderV3.DeleteByPK(UserInfo{}, uid)
+	_, err = stmt.ExecPG(conn, ctx)
+	stmt.Destroy()
 	if err != nil {
 		return err
 	}